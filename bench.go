@@ -0,0 +1,245 @@
+// The bench command: time the algorithms over several numbers of digits.
+//
+// Every combination runs as a compute command of its own, so that its peak
+// memory is not mixed up with the runs before it; the times come from the
+// reports of the runs and leave out the start of the process. With several
+// runs per combination, the result is the median time and the largest
+// peak memory, see benchcompare.go for the comparison of results.
+
+package main
+
+import (
+    "bytes"
+    "encoding/csv"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "log/slog"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "runtime"
+    "strconv"
+    "strings"
+    "text/tabwriter"
+    "time"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+var benchCommand = &command{
+    name:  "bench",
+    args:  "",
+    short: "time the algorithms for several numbers of digits",
+    setup: setupBench,
+}
+
+// The result of one combination of algorithm and digits
+type benchResult struct {
+    Algorithm       string    `json:"algorithm"`
+    Digits          int       `json:"digits"`
+    WallTime        float64   `json:"wall_time_seconds"`
+    PeakMemory      uint64    `json:"peak_memory_bytes"`
+    DigitsPerSecond float64   `json:"digits_per_second"`
+    WallTimes       []float64 `json:"run_wall_times_seconds,omitempty"`
+}
+
+// What bench -format json writes
+type benchReport struct {
+    Results []benchResult `json:"results"`
+
+    machine
+}
+
+func setupBench(fs *flag.FlagSet) func(args []string) error {
+    digits := fs.String("digits", "1e3,1e4,1e5",
+        "comma separated numbers of digits")
+    algos := fs.String("algos", strings.Join(pi.Algorithms(), ","),
+        "comma separated algorithms")
+    format := fs.String("format", "table",
+        "output format: table, csv or json")
+    output := fs.String("output", "", "write the results to this file")
+    out := fs.String("out", "",
+        "also write the results as JSON to this file, for bench compare")
+    runs := fs.Int("runs", 1,
+        "run every combination this many times, at least 2 for the "+
+            "test of bench compare")
+    compare := defineBenchCompare(fs)
+
+    return func(args []string) error {
+        if len(args) > 0 && args[0] == "compare" {
+            return compare(args[1:])
+        }
+        if len(args) > 0 {
+            return usagef("unexpected arguments")
+        }
+        if *runs < 1 {
+            return usagef("invalid number of runs %d", *runs)
+        }
+        counts, err := parseDigitCounts(*digits)
+        if err != nil {
+            return err
+        }
+        names := strings.Split(*algos, ",")
+        for _, name := range names {
+            if err := checkAlgorithm(name); err != nil {
+                return err
+            }
+        }
+        write, ok := benchFormats[*format]
+        if !ok {
+            return usagef("unknown format %q, choose table, csv or json",
+                *format)
+        }
+
+        report := &benchReport{machine: newMachine()}
+        for _, name := range names {
+            for _, n := range counts {
+                slog.Info(fmt.Sprintf("%s with %d digits", name, n),
+                    "algorithm", name, "digits", n)
+                result, err := runBenchRepeated(name, n, *runs)
+                if err != nil {
+                    return err
+                }
+                report.Results = append(report.Results, result)
+            }
+        }
+        if *out != "" {
+            err := writeOutput(*out, func(w io.Writer) error {
+                return writeBenchJSON(w, report)
+            })
+            if err != nil {
+                return err
+            }
+        }
+        return writeOutput(*output, func(w io.Writer) error {
+            return write(w, report)
+        })
+    }
+}
+
+// Parse numbers of digits like "1000,1e5"
+func parseDigitCounts(s string) ([]int, error) {
+    var counts []int
+    for _, field := range strings.Split(s, ",") {
+        x, err := parsePlaces(field)
+        if err != nil {
+            return nil, err
+        }
+        counts = append(counts, x)
+    }
+    return counts, nil
+}
+
+// Run the combination the given number of times, the result has the
+// median time, the largest peak memory and all the times
+func runBenchRepeated(algo string, places, runs int) (benchResult, error) {
+    var result benchResult
+    for i := 0; i < runs; i++ {
+        r, err := runBench(algo, places)
+        if err != nil {
+            return benchResult{}, err
+        }
+        result.PeakMemory = max(result.PeakMemory, r.PeakMemory)
+        result.WallTimes = append(result.WallTimes, r.WallTime)
+    }
+    result.Algorithm, result.Digits = algo, places
+    result.WallTime = median(result.WallTimes)
+    if result.WallTime > 0 {
+        result.DigitsPerSecond = float64(places) / result.WallTime
+    }
+    if runs == 1 {
+        result.WallTimes = nil
+    }
+    return result, nil
+}
+
+// Compute the digits with the algorithm in a process of its own
+func runBench(algo string, places int) (benchResult, error) {
+    exe, err := os.Executable()
+    if err != nil {
+        return benchResult{}, err
+    }
+    dir, err := os.MkdirTemp("", "pi-bench-")
+    if err != nil {
+        return benchResult{}, err
+    }
+    defer os.RemoveAll(dir)
+    reportFile := filepath.Join(dir, "report.json")
+
+    // GOMAXPROCS as bounded by -j, for the computation as well
+    cmd := exec.Command(exe, "compute", "-quiet", "-spotcheck=false",
+        "-algo", algo, "-report", reportFile, "-output", os.DevNull,
+        "-j", strconv.Itoa(runtime.GOMAXPROCS(0)), strconv.Itoa(places))
+    var stderr bytes.Buffer
+    cmd.Stderr = &stderr
+    if err := cmd.Run(); err != nil {
+        return benchResult{}, fmt.Errorf("%s with %d digits: %v %s", algo,
+            places, err, strings.TrimSpace(stderr.String()))
+    }
+
+    data, err := os.ReadFile(reportFile)
+    if err != nil {
+        return benchResult{}, err
+    }
+    var run runReport
+    if err := json.Unmarshal(data, &run); err != nil {
+        return benchResult{}, err
+    }
+    result := benchResult{
+        Algorithm:  algo,
+        Digits:     places,
+        WallTime:   run.WallTime,
+        PeakMemory: run.PeakMemory,
+    }
+    if run.WallTime > 0 {
+        result.DigitsPerSecond = float64(places) / run.WallTime
+    }
+    return result, nil
+}
+
+// The output formats of bench
+var benchFormats = map[string]func(w io.Writer, r *benchReport) error{
+    "table": writeBenchTable,
+    "csv":   writeBenchCSV,
+    "json":  writeBenchJSON,
+}
+
+func writeBenchTable(w io.Writer, r *benchReport) error {
+    tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+    fmt.Fprintf(tw, "algorithm\tdigits\ttime\tpeak memory\tdigits/s\t\n")
+    for _, x := range r.Results {
+        elapsed := time.Duration(x.WallTime * float64(time.Second))
+        fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%.0f\t\n", x.Algorithm, x.Digits,
+            roundDuration(elapsed), formatBytes(x.PeakMemory),
+            x.DigitsPerSecond)
+    }
+    return tw.Flush()
+}
+
+func writeBenchCSV(w io.Writer, r *benchReport) error {
+    cw := csv.NewWriter(w)
+    cw.Write([]string{"algorithm", "digits", "wall_time_seconds",
+        "peak_memory_bytes", "digits_per_second"})
+    for _, x := range r.Results {
+        cw.Write([]string{
+            x.Algorithm,
+            strconv.Itoa(x.Digits),
+            strconv.FormatFloat(x.WallTime, 'f', 6, 64),
+            strconv.FormatUint(x.PeakMemory, 10),
+            strconv.FormatFloat(x.DigitsPerSecond, 'f', 0, 64),
+        })
+    }
+    cw.Flush()
+    return cw.Error()
+}
+
+func writeBenchJSON(w io.Writer, r *benchReport) error {
+    data, err := json.MarshalIndent(r, "", "    ")
+    if err != nil {
+        return err
+    }
+    _, err = w.Write(append(data, '\n'))
+    return err
+}
@@ -0,0 +1,250 @@
+// bench compare: the results of two bench runs side by side, with the
+// slowdowns flagged.
+//
+// A combination of algorithm and digits is slower if its median time grew
+// by more than the threshold and, where both results have several runs,
+// Welch's t-test on the run times finds the growth significant. With a
+// single run on either side there is nothing to test, a growth beyond the
+// threshold is flagged as unconfirmed only.
+
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "math"
+    "os"
+    "sort"
+    "strings"
+    "text/tabwriter"
+    "time"
+)
+
+// Define the flags of bench compare and return the function running it
+func defineBenchCompare(fs *flag.FlagSet) func(args []string) error {
+    threshold := fs.Float64("threshold", 5,
+        "bench compare: flag time growths of more than this many percent")
+    alpha := fs.Float64("alpha", 0.05,
+        "bench compare: significance level of the test of the run times")
+
+    return func(args []string) error {
+        // The flags may follow compare as well
+        if err := fs.Parse(args); err != nil {
+            return err
+        }
+        args = fs.Args()
+        if len(args) != 2 {
+            return usagef("usage: bench compare old.json new.json")
+        }
+        if *threshold < 0 || *alpha <= 0 || *alpha >= 1 {
+            return usagef("invalid -threshold %g or -alpha %g", *threshold,
+                *alpha)
+        }
+        old, err := readBenchReport(args[0])
+        if err != nil {
+            return err
+        }
+        cur, err := readBenchReport(args[1])
+        if err != nil {
+            return err
+        }
+        slower := compareBench(old, cur, *threshold/100, *alpha)
+        if slower > 0 {
+            return fmt.Errorf("slowdowns: %d", slower)
+        }
+        return nil
+    }
+}
+
+func readBenchReport(name string) (*benchReport, error) {
+    data, err := os.ReadFile(name)
+    if err != nil {
+        return nil, err
+    }
+    var r benchReport
+    if err := json.Unmarshal(data, &r); err != nil {
+        return nil, fmt.Errorf("%s: %v", name, err)
+    }
+    return &r, nil
+}
+
+// Print the combinations of both reports and return the number of
+// significant slowdowns
+func compareBench(old, cur *benchReport, threshold, alpha float64) int {
+    type key struct {
+        algo   string
+        digits int
+    }
+    before := make(map[key]benchResult)
+    for _, x := range old.Results {
+        before[key{x.Algorithm, x.Digits}] = x
+    }
+
+    tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+    fmt.Fprintf(tw, "algorithm\tdigits\told\tnew\tchange\tp\t\t\n")
+    slower := 0
+    for _, x := range cur.Results {
+        y, ok := before[key{x.Algorithm, x.Digits}]
+        if !ok || y.WallTime <= 0 {
+            continue
+        }
+        change := x.WallTime/y.WallTime - 1
+        p, tested := slowdownP(y.times(), x.times())
+
+        verdict, shown := "", "-"
+        if tested {
+            shown = fmt.Sprintf("%.3f", p)
+        }
+        switch {
+        case change > threshold && tested && p < alpha:
+            verdict = "SLOWER"
+            slower++
+        case change > threshold && !tested:
+            verdict = "slower?"
+        case change < -threshold && tested && p > 1-alpha:
+            verdict = "faster"
+        }
+        fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%+.1f%%\t%s\t%s\t\n", x.Algorithm,
+            x.Digits, formatSeconds(y.WallTime), formatSeconds(x.WallTime),
+            100*change, shown, verdict)
+    }
+    tw.Flush()
+    // Times of different machines are hardly comparable
+    if a, b := old.machine.describe(), cur.machine.describe(); a != b {
+        fmt.Printf("\nold: %s\nnew: %s\n", a, b)
+    }
+    return slower
+}
+
+// Return the times of the runs, the one time of a single run
+func (r benchResult) times() []float64 {
+    if len(r.WallTimes) > 0 {
+        return r.WallTimes
+    }
+    return []float64{r.WallTime}
+}
+
+// Return the machine in a line, without the time it ran
+func (m machine) describe() string {
+    cpus := fmt.Sprintf("%d CPUs", m.CPUs)
+    if m.Procs > 0 && m.Procs != m.CPUs {
+        cpus += fmt.Sprintf(", GOMAXPROCS %d", m.Procs)
+    }
+    return strings.Join([]string{m.OS + "/" + m.Arch, cpus, m.GoVersion,
+        m.Backend}, ", ")
+}
+
+func formatSeconds(s float64) string {
+    return roundDuration(time.Duration(s * float64(time.Second))).String()
+}
+
+// Return the median of the values
+func median(values []float64) float64 {
+    if len(values) == 0 {
+        return 0
+    }
+    sorted := append([]float64(nil), values...)
+    sort.Float64s(sorted)
+    n := len(sorted)
+    if n%2 == 1 {
+        return sorted[n/2]
+    }
+    return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// Return the p-value of Welch's t-test for the new times being larger
+// than the old ones, one sided, and whether there are enough runs, two on
+// either side, to test at all
+func slowdownP(old, cur []float64) (float64, bool) {
+    if len(old) < 2 || len(cur) < 2 {
+        return 0, false
+    }
+    m1, v1 := meanVariance(old)
+    m2, v2 := meanVariance(cur)
+    s1, s2 := v1/float64(len(old)), v2/float64(len(cur))
+    if s1+s2 == 0 {
+        // No noise at all, any growth is significant
+        if m2 > m1 {
+            return 0, true
+        }
+        return 1, true
+    }
+    t := (m2 - m1) / math.Sqrt(s1+s2)
+    dof := (s1 + s2) * (s1 + s2) /
+        (s1*s1/float64(len(old)-1) + s2*s2/float64(len(cur)-1))
+
+    // P(T >= t) for Student's t distribution
+    tail := incompleteBeta(dof/2, 0.5, dof/(dof+t*t)) / 2
+    if t < 0 {
+        return 1 - tail, true
+    }
+    return tail, true
+}
+
+// Return the mean and the sample variance of the values
+func meanVariance(values []float64) (float64, float64) {
+    var sum float64
+    for _, x := range values {
+        sum += x
+    }
+    mean := sum / float64(len(values))
+    var squares float64
+    for _, x := range values {
+        squares += (x - mean) * (x - mean)
+    }
+    return mean, squares / float64(len(values)-1)
+}
+
+// Return the regularized incomplete beta function I_x(a, b), with the
+// continued fraction as in Numerical Recipes
+func incompleteBeta(a, b, x float64) float64 {
+    if x <= 0 {
+        return 0
+    }
+    if x >= 1 {
+        return 1
+    }
+    la, _ := math.Lgamma(a)
+    lb, _ := math.Lgamma(b)
+    lab, _ := math.Lgamma(a + b)
+    front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+    // The continued fraction converges fast below the mean
+    if x > (a+1)/(a+b+2) {
+        return 1 - front*betaFraction(b, a, 1-x)/b
+    }
+    return front * betaFraction(a, b, x) / a
+}
+
+// The continued fraction of incompleteBeta by the modified Lentz's method
+func betaFraction(a, b, x float64) float64 {
+    const epsilon, tiny = 1e-15, 1e-300
+    c, d := 1.0, 1-(a+b)*x/(a+1)
+    if math.Abs(d) < tiny {
+        d = tiny
+    }
+    d = 1 / d
+    h := d
+    for m := 1.0; m < 1000; m++ {
+        // The even and the odd step
+        for _, an := range []float64{
+            m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m)),
+            -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1)),
+        } {
+            d = 1 + an*d
+            if math.Abs(d) < tiny {
+                d = tiny
+            }
+            c = 1 + an/c
+            if math.Abs(c) < tiny {
+                c = tiny
+            }
+            d = 1 / d
+            h *= d * c
+        }
+        if math.Abs(d*c-1) < epsilon {
+            break
+        }
+    }
+    return h
+}
@@ -0,0 +1,86 @@
+// The cf command: the simple continued fraction of pi.
+
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "log/slog"
+    "math/big"
+    "strings"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+var cfCommand = &command{
+    name:  "cf",
+    args:  "",
+    short: "print the terms of the simple continued fraction of pi",
+    setup: setupCF,
+}
+
+func setupCF(fs *flag.FlagSet) func(args []string) error {
+    terms := fs.Int("terms", 20,
+        "number of terms, computed with enough digits to certify them")
+    places := fs.Int("digits", 0,
+        "instead of -terms, compute this many digits and print all terms\n"+
+            "they certify")
+    algo := fs.String("algo", pi.DefaultAlgorithm,
+        "algorithm: "+strings.Join(pi.Algorithms(), ", ")+" or auto")
+
+    return func(args []string) error {
+        if len(args) > 0 {
+            return usagef("unexpected arguments")
+        }
+        if *terms < 1 {
+            return usagef("invalid number of terms %d", *terms)
+        }
+        if *places < 0 {
+            return usagef("invalid number of digits %d", *places)
+        }
+        if err := checkAlgorithm(*algo); err != nil {
+            return err
+        }
+
+        opts := &pi.Options{Algorithm: *algo}
+        var cf []*big.Int
+        var err error
+        if *places > 0 {
+            var x *big.Int
+            x, err = pi.Compute(context.Background(), *places, opts)
+            if err == nil {
+                cf = pi.ContinuedFractionOf(x, *places, -1)
+            }
+        } else {
+            cf, *places, err = pi.ContinuedFraction(context.Background(),
+                *terms, opts)
+        }
+        if err != nil {
+            return err
+        }
+
+        fmt.Println(formatCF(cf))
+        slog.Info(fmt.Sprintf("%d terms certified by %d digits", len(cf),
+            *places), "terms", len(cf), "digits", *places)
+        return nil
+    }
+}
+
+// Return the terms as [a0; a1, a2, ...]
+func formatCF(cf []*big.Int) string {
+    var b strings.Builder
+    b.WriteString("[")
+    for i, a := range cf {
+        switch i {
+        case 0:
+        case 1:
+            b.WriteString("; ")
+        default:
+            b.WriteString(", ")
+        }
+        b.WriteString(a.String())
+    }
+    b.WriteString("]")
+    return b.String()
+}
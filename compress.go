@@ -0,0 +1,145 @@
+// Compressing the output while it is written, for -compress, and reading
+// it back.
+//
+// gzip comes with the standard library, zstd is handed to the zstd command,
+// which needs to be installed.
+
+package main
+
+import (
+    "bufio"
+    "compress/gzip"
+    "fmt"
+    "io"
+    "os"
+    "os/exec"
+    "strings"
+)
+
+// Return write with its output compressed by the given method, write itself
+// if the method is empty
+func compressed(method string,
+    write func(w io.Writer) error) func(w io.Writer) error {
+    switch method {
+    case "gzip":
+        return func(w io.Writer) error {
+            zw := gzip.NewWriter(w)
+            if err := write(zw); err != nil {
+                return err
+            }
+            return zw.Close()
+        }
+    case "zstd":
+        return func(w io.Writer) error {
+            return writeZstd(w, write)
+        }
+    }
+    return write
+}
+
+// Return the file name suffix of the compression method
+func compressedSuffix(method string) string {
+    switch method {
+    case "gzip":
+        return ".gz"
+    case "zstd":
+        return ".zst"
+    }
+    return ""
+}
+
+// Write through the zstd command into w
+func writeZstd(w io.Writer, write func(w io.Writer) error) error {
+    path, err := exec.LookPath("zstd")
+    if err != nil {
+        return fmt.Errorf("-compress zstd needs the zstd command: %v", err)
+    }
+    cmd := exec.Command(path, "-q", "-c")
+    cmd.Stdout = w
+    in, err := cmd.StdinPipe()
+    if err != nil {
+        return err
+    }
+    if err := cmd.Start(); err != nil {
+        return err
+    }
+
+    err = write(in)
+    if cerr := in.Close(); err == nil {
+        err = cerr
+    }
+    if werr := cmd.Wait(); err == nil && werr != nil {
+        err = fmt.Errorf("zstd: %v", werr)
+    }
+    return err
+}
+
+// The magic numbers starting compressed files
+const (
+    gzipMagic = "\x1f\x8b"
+    zstdMagic = "\x28\xb5\x2f\xfd"
+)
+
+// Open the named file and return its content, decompressed if it is
+// compressed, and whether it is; the caller closes the returned closer
+func openDecompressed(name string) (*bufio.Reader, io.Closer, bool,
+    error) {
+    f, err := os.Open(name)
+    if err != nil {
+        return nil, nil, false, err
+    }
+    r := bufio.NewReaderSize(f, 1<<16)
+    magic, _ := r.Peek(len(zstdMagic))
+    switch {
+    case strings.HasPrefix(string(magic), gzipMagic):
+        zr, err := gzip.NewReader(r)
+        if err != nil {
+            f.Close()
+            return nil, nil, false, fmt.Errorf("%s: %v", name, err)
+        }
+        return bufio.NewReaderSize(zr, 1<<16), f, true, nil
+    case string(magic) == zstdMagic:
+        zr, err := startUnzstd(r, f)
+        if err != nil {
+            f.Close()
+            return nil, nil, false, fmt.Errorf("%s: %v", name, err)
+        }
+        return bufio.NewReaderSize(zr, 1<<16), zr, true, nil
+    }
+    return r, f, false, nil
+}
+
+// The output of the zstd command decompressing a file
+type unzstd struct {
+    io.ReadCloser
+    cmd  *exec.Cmd
+    file *os.File
+}
+
+// Start the zstd command decompressing r, read from file
+func startUnzstd(r io.Reader, file *os.File) (*unzstd, error) {
+    path, err := exec.LookPath("zstd")
+    if err != nil {
+        return nil, fmt.Errorf("reading zstd needs the zstd command: %v", err)
+    }
+    cmd := exec.Command(path, "-q", "-d", "-c")
+    cmd.Stdin = r
+    out, err := cmd.StdoutPipe()
+    if err != nil {
+        return nil, err
+    }
+    if err := cmd.Start(); err != nil {
+        return nil, err
+    }
+    return &unzstd{out, cmd, file}, nil
+}
+
+// Stop the command and close the file
+func (z *unzstd) Close() error {
+    z.ReadCloser.Close()
+    err := z.cmd.Wait()
+    if cerr := z.file.Close(); err == nil {
+        err = cerr
+    }
+    return err
+}
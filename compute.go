@@ -0,0 +1,864 @@
+// The compute command: print pi, or another constant, with the requested
+// number of digits.
+
+package main
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "flag"
+    "fmt"
+    "go/token"
+    "io"
+    "log/slog"
+    "math"
+    "math/big"
+    "os"
+    "os/exec"
+    "runtime/debug"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+const (
+    defaultPlaces = 1000
+
+    // Default of -max-digits, a safety limit against mistyped numbers
+    defaultMaxPlaces = 1000000000
+
+    // Largest number of digits accepted at all
+    maxPlaces = math.MaxInt32
+)
+
+// Computations with at least this many digits get a BBP spot-check
+const spotcheckPlaces = 10000
+
+var computeCommand = &command{
+    name:  "compute",
+    args:  "[digits]",
+    short: "print pi or another constant, 1000 digits by default",
+    setup: setupCompute,
+}
+
+// The flags of the compute command
+type computeFlags struct {
+    digits      int
+    bits        int
+    constant    string
+    tau         bool
+    expr        string
+    algo        string
+    base        int
+    round       bool
+    certified   bool
+    output      string
+    progress    bool
+    timeout     time.Duration
+    spotcheck   bool
+    extractAlgo string
+    verifyWith  string
+    report      string
+    checkpoint  string
+    extendTo    string
+    traceTerms  string
+    trace       *traceWriter
+    estimate    bool
+    maxMem      string
+    memLimit    uint64
+    profile     profileFlags
+    stats       bool
+    offset      int
+    length      int
+    tail        int
+    group       int
+    line        int
+    numberLines bool
+    raw         bool
+    leading     bool
+    format      string
+    compress    string
+    shardSize   int
+    pkg         string
+    maxDigits   int
+}
+
+func setupCompute(fs *flag.FlagSet) func(args []string) error {
+    f := &computeFlags{}
+    fs.IntVar(&f.digits, "digits", -1,
+        "number of digits after the decimal point, also accepted as\n"+
+            "argument")
+    // -1 stands for not given, the usage shows what that amounts to
+    fs.Lookup("digits").DefValue = strconv.Itoa(defaultPlaces)
+    fs.IntVar(&f.bits, "bits", 0,
+        "precision in bits instead of digits: print as many digits as\n"+
+            "needed to carry this many bits after the point")
+    fs.StringVar(&f.constant, "constant", "pi",
+        "constant to compute: "+strings.Join(pi.Constants(), ", "))
+    fs.BoolVar(&f.tau, "tau", false,
+        "print tau = 2*pi, the same as -expr 2*pi")
+    fs.StringVar(&f.expr, "expr", "",
+        "print this expression in pi instead, e.g. \"pi^2/6\", with\n"+
+            "+ - * / ^, integer exponents and parentheses")
+    fs.StringVar(&f.algo, "algo", pi.DefaultAlgorithm,
+        "algorithm for pi: "+strings.Join(pi.Algorithms(), ", ")+
+            ", or auto\nfor the fastest one")
+    fs.IntVar(&f.base, "base", 10,
+        "write the digits in this base, 2 to 36")
+    fs.BoolVar(&f.round, "round", false,
+        "round the last digit to nearest instead of truncating")
+    fs.BoolVar(&f.certified, "certified", false,
+        "carry lower and upper bounds through the computation and print\n"+
+            "only the digits on which they agree")
+    fs.StringVar(&f.output, "output", "",
+        "write the digits to this file instead of stdout")
+    fs.BoolVar(&f.progress, "progress", false,
+        "report the progress on stderr")
+    fs.DurationVar(&f.timeout, "timeout", 0,
+        "compute as many digits as possible within the given time, with\n"+
+            "digits as upper limit")
+    fs.BoolVar(&f.spotcheck, "spotcheck", true,
+        fmt.Sprintf("check the last hex digits of computations with at "+
+            "least\n%d digits by digit extraction", spotcheckPlaces))
+    fs.StringVar(&f.extractAlgo, "extract-algo", pi.ExtractAlgorithms()[0],
+        "digit extraction formula of the spot-check: "+
+            strings.Join(pi.ExtractAlgorithms(), ", "))
+    fs.StringVar(&f.verifyWith, "verify-with", "",
+        "recompute with this second algorithm and only print the digits\n"+
+            "if both results agree")
+    fs.StringVar(&f.report, "report", "",
+        "write a JSON report of the run to this file")
+    fs.StringVar(&f.checkpoint, "checkpoint", "",
+        "save the state of the series to this file while computing, and\n"+
+            "continue from the state in it, with -algo chudnovsky")
+    fs.StringVar(&f.extendTo, "extend-to", "",
+        "compute this many digits from the existing -checkpoint, summing\n"+
+            "only the series terms it is missing")
+    fs.StringVar(&f.traceTerms, "trace-terms", "",
+        "write one CSV row per series term to this file: its size and\n"+
+            "the digits certified so far, with -algo machin or chudnovsky,\n"+
+            "ln2 or ln10")
+    fs.BoolVar(&f.estimate, "estimate", false,
+        "print the predicted peak memory and running time, calibrated by\n"+
+            "a few small runs, instead of computing")
+    fs.StringVar(&f.maxMem, "max-mem", "",
+        "cap the memory, e.g. 8GiB: refuse to start if the computation\n"+
+            "is predicted to need more")
+    fs.IntVar(&f.offset, "offset", 0,
+        "print only the digits from this place after the point on, the\n"+
+            "first place is 1; with -length instead of the number of digits")
+    fs.IntVar(&f.length, "length", 0,
+        "number of digits printed from -offset on")
+    fs.IntVar(&f.tail, "tail", 0,
+        "print only the last digits, this many, after their places")
+    fs.IntVar(&f.group, "group", 0,
+        "separate the digits after the point in groups of this many")
+    fs.IntVar(&f.line, "line", 0,
+        "print this many digits after the point per line")
+    fs.BoolVar(&f.numberLines, "number-lines", false,
+        "start every line with the place of its first digit, with -line")
+    fs.BoolVar(&f.raw, "raw", false,
+        "print the digits only, without the point and the final newline")
+    fs.BoolVar(&f.leading, "leading", false,
+        "with -raw, include the integer part, e.g. the 3 of pi; digit\n"+
+            "files cannot tell it from the places then")
+    fs.StringVar(&f.format, "format", "text",
+        "output format: text, json with the digits as value next to the\n"+
+            "algorithm, SHA-256 and elapsed time, ycd for the compressed\n"+
+            "digit files of y-cruncher, or gosrc for a Go file with the\n"+
+            "digits as constant")
+    fs.StringVar(&f.pkg, "package", "pidigits",
+        "package of the Go file of -format gosrc")
+    fs.StringVar(&f.compress, "compress", "",
+        "compress the output while writing it: gzip, or zstd with the\n"+
+            "zstd command")
+    fs.IntVar(&f.shardSize, "shard-size", 0,
+        "split the digits after the point in numbered files of this many\n"+
+            "digits, named after -output, with a manifest of their places\n"+
+            "and checksums")
+    fs.IntVar(&f.maxDigits, "max-digits", defaultMaxPlaces,
+        "refuse to compute more digits than this, 0 for no limit")
+    f.profile.define(fs)
+    fs.BoolVar(&f.stats, "stats", false,
+        "print how the time went on series, combination, conversion to\n"+
+            "digits and output on stderr, and add it to the report")
+    return f.runProfiled
+}
+
+// Run the command under the requested profiles
+func (f *computeFlags) runProfiled(args []string) error {
+    stop, err := f.profile.start()
+    if err != nil {
+        return err
+    }
+    err = f.run(args)
+    if f.trace != nil {
+        if terr := f.trace.close(); err == nil {
+            err = terr
+        }
+    }
+    if serr := stop(); err == nil {
+        err = serr
+    }
+    return err
+}
+
+func (f *computeFlags) check() error {
+    if f.progress && !logging(slog.LevelInfo) {
+        return usagef("-quiet and -progress exclude each other")
+    }
+    if f.timeout < 0 {
+        return usagef("invalid timeout %s", f.timeout)
+    }
+    if f.maxDigits < 0 {
+        return usagef("invalid -max-digits %d", f.maxDigits)
+    }
+    if f.base < 2 || f.base > 36 {
+        return usagef("invalid base %d, choose one from 2 to 36", f.base)
+    }
+    if err := checkConstant(f.constant); err != nil {
+        return err
+    }
+    if f.tau {
+        if f.expr != "" {
+            return usagef("-tau and -expr exclude each other")
+        }
+        f.expr = "2*pi"
+    }
+    if f.expr != "" {
+        if f.constant != "pi" {
+            return usagef("-expr needs -constant pi")
+        }
+        if err := pi.CheckExpr(f.expr); err != nil {
+            return usagef("%s", strings.TrimPrefix(err.Error(), "pi: "))
+        }
+    }
+    if err := checkAlgorithm(f.algo); err != nil {
+        return err
+    }
+    if f.algo == "auto" {
+        f.algo = pi.AutoAlgorithm(nil)
+    }
+    if err := checkExtractAlgorithm(f.extractAlgo); err != nil {
+        return err
+    }
+    if f.certified {
+        switch {
+        case f.expr != "":
+            return usagef("-certified does not support expressions")
+        case f.timeout > 0:
+            return usagef("-certified and -timeout exclude each other")
+        case f.verifyWith != "":
+            return usagef("-certified and -verify-with exclude each other")
+        case f.constant == "pi" && f.algo == "borwein":
+            return usagef("-certified needs -algo machin or chudnovsky")
+        }
+    }
+    if f.traceTerms != "" {
+        switch {
+        case f.expr != "" || !f.traceSupported():
+            return usagef("-trace-terms needs pi with -algo machin or " +
+                "chudnovsky, ln2 or ln10")
+        case f.certified || f.timeout > 0:
+            return usagef("-trace-terms excludes -certified and -timeout")
+        }
+    }
+    if f.checkpoint != "" {
+        switch {
+        case f.constant != "pi" || f.expr != "" || f.algo != "chudnovsky":
+            return usagef("-checkpoint needs pi with -algo chudnovsky")
+        case f.certified || f.timeout > 0:
+            return usagef("-checkpoint excludes -certified and -timeout")
+        }
+    }
+    if f.extendTo != "" && f.checkpoint == "" {
+        return usagef("-extend-to needs the -checkpoint to extend")
+    }
+    if f.offset != 0 || f.length != 0 {
+        switch {
+        case f.offset < 1 || f.length < 1:
+            return usagef("-offset and -length need positive values, " +
+                "e.g. -offset 1000000 -length 50")
+        case f.timeout > 0:
+            return usagef("-offset and -timeout exclude each other")
+        case f.certified:
+            return usagef("-offset and -certified exclude each other")
+        case f.bits != 0:
+            return usagef("-offset and -bits exclude each other")
+        }
+    }
+    if f.tail != 0 {
+        switch {
+        case f.tail < 0:
+            return usagef("invalid number of digits %d", f.tail)
+        case f.offset != 0:
+            return usagef("-tail and -offset exclude each other")
+        }
+    }
+    if f.group != 0 || f.line != 0 || f.numberLines {
+        switch {
+        case f.group < 0 || f.line < 0:
+            return usagef("invalid layout -group %d -line %d", f.group,
+                f.line)
+        case f.numberLines && f.line == 0:
+            return usagef("-number-lines needs -line")
+        case f.tail != 0:
+            return usagef("-tail prints no layout")
+        }
+    }
+    if f.raw {
+        switch {
+        case f.tail != 0:
+            return usagef("-raw and -tail exclude each other")
+        case f.group != 0 || f.line != 0:
+            return usagef("-raw prints no layout")
+        }
+    }
+    switch f.format {
+    case "text":
+    case "json", "ycd", "gosrc":
+        if f.raw || f.tail != 0 || f.group != 0 || f.line != 0 {
+            return usagef("-format %s excludes -raw, -tail and layouts",
+                f.format)
+        }
+        if f.format == "ycd" {
+            if ycdWordDigits(f.base) == 0 {
+                return usagef("-format ycd needs -base 10 or 16")
+            }
+            if f.offset != 0 {
+                return usagef("-format ycd holds all digits, not -offset")
+            }
+        }
+        if f.format == "gosrc" && !token.IsIdentifier(f.pkg) {
+            return usagef("invalid package name %q", f.pkg)
+        }
+    default:
+        return usagef("unknown format %q, choose text, json, ycd or gosrc",
+            f.format)
+    }
+    switch f.compress {
+    case "", "gzip":
+    case "zstd":
+        // Better now than after the computation
+        if _, err := exec.LookPath("zstd"); err != nil {
+            return fmt.Errorf("-compress zstd needs the zstd command: %v",
+                err)
+        }
+    default:
+        return usagef("unknown compression %q, choose gzip or zstd",
+            f.compress)
+    }
+    if f.shardSize != 0 {
+        switch {
+        case f.shardSize < 0:
+            return usagef("invalid shard size %d", f.shardSize)
+        case f.output == "":
+            return usagef("-shard-size needs -output to name the shards")
+        case f.format != "text" || f.raw || f.tail != 0 || f.offset != 0 ||
+            f.group != 0 || f.line != 0:
+            return usagef("-shard-size writes plain digits, it excludes " +
+                "-format, -raw, -tail, -offset and layouts")
+        }
+    }
+    if f.maxMem != "" {
+        n, err := parseBytes(f.maxMem)
+        if err != nil || n == 0 {
+            return usagef("invalid memory size %q, e.g. 512MiB or 8GiB",
+                f.maxMem)
+        }
+        f.memLimit = n
+    }
+    if f.estimate {
+        switch {
+        case f.timeout > 0:
+            return usagef("-estimate and -timeout exclude each other")
+        case f.certified:
+            return usagef("-estimate and -certified exclude each other")
+        }
+    }
+    if f.verifyWith != "" {
+        if f.constant != "pi" {
+            return usagef("-verify-with needs -constant pi")
+        }
+        if err := checkAlgorithm(f.verifyWith); err != nil {
+            return err
+        }
+        if f.verifyWith == "auto" {
+            f.verifyWith = pi.AutoAlgorithm(nil)
+        }
+        if f.verifyWith == f.algo {
+            return usagef("-verify-with needs an algorithm other than %s",
+                f.algo)
+        }
+    }
+    return nil
+}
+
+func (f *computeFlags) run(args []string) error {
+    places, err := placesArgument(f.digits, args)
+    if err != nil {
+        return err
+    }
+    if err := f.check(); err != nil {
+        return err
+    }
+    if f.bits != 0 {
+        if f.bits < 0 {
+            return usagef("invalid number of bits %d", f.bits)
+        }
+        if places >= 0 {
+            return usagef("-bits and the number of digits exclude each other")
+        }
+        places = pi.PlacesForBits(f.bits, f.base)
+    }
+    if f.offset != 0 {
+        if places >= 0 {
+            return usagef("-offset and the number of digits exclude each other")
+        }
+        // Just enough places for the last digit of the window
+        places = f.offset + f.length - 1
+    }
+    if f.extendTo != "" {
+        if places >= 0 {
+            return usagef("-extend-to and the number of digits exclude " +
+                "each other")
+        }
+        if places, err = f.extension(); err != nil {
+            return err
+        }
+    }
+    if f.maxDigits > 0 && places > f.maxDigits {
+        return usagef("%d digits exceed -max-digits %d, raise it to "+
+            "compute them anyway", places, f.maxDigits)
+    }
+
+    start := time.Now()
+    opts := &pi.Options{
+        Constant:   f.constant,
+        Expr:       f.expr,
+        Algorithm:  f.algo,
+        Base:       f.base,
+        Round:      f.round,
+        Checkpoint: f.checkpoint,
+    }
+    var printer func(pi.Progress)
+    if f.progress {
+        printer = newProgressPrinter(time.Second).update
+    }
+    status := newStatusReporter(printer)
+    opts.Progress = status.update
+    defer status.watch()()
+    if f.traceTerms != "" && !f.estimate {
+        if f.trace, err = createTrace(f.traceTerms); err != nil {
+            return err
+        }
+        opts.Trace = f.trace.term
+    }
+    if f.stats {
+        opts.Timing = &pi.Timing{}
+    }
+    if f.estimate {
+        if places < 0 {
+            places = defaultPlaces
+        }
+        return runEstimate(places, opts)
+    }
+    if f.memLimit > 0 {
+        if err := f.capMemory(places, opts); err != nil {
+            return err
+        }
+    }
+
+    slog.Debug(fmt.Sprintf("computing %s with %s in base %d", f.what(places),
+        f.algo, f.base), "digits", places, "constant", f.constant,
+        "expression", f.expr, "algorithm", f.algo, "base", f.base)
+    report := newRunReport(f.constant, f.algo, f.base)
+    report.Expr = f.expr
+    report.Bits = f.bits
+    report.Rounded = f.round
+    if f.certified {
+        return f.runCertified(places, opts, report, start)
+    }
+
+    var x *big.Int
+    if f.timeout > 0 {
+        // Time budget: the number of digits is an upper limit only
+        ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+        defer cancel()
+        x, places, err = pi.ComputeLargest(ctx, places, opts)
+        if err != nil {
+            return err
+        }
+        slog.Info(fmt.Sprintf("computed %d digits within %s", places,
+            f.timeout), "digits", places, "timeout", f.timeout)
+    } else {
+        if places < 0 {
+            places = defaultPlaces
+        }
+        var correct int
+        x, correct, err = pi.ComputeCertified(context.Background(), places,
+            opts)
+        if err != nil {
+            return err
+        }
+        if correct < places {
+            slog.Warn(fmt.Sprintf("only %d of %d digits are certified "+
+                "correct by the error bound", correct, places),
+                "certified", correct, "digits", places)
+        }
+        report.Certified = &correct
+    }
+
+    // Digit extraction knows the digits of pi only
+    if f.spotcheck && f.constant == "pi" && f.expr == "" &&
+        places >= spotcheckPlaces {
+        err := checkTail(x, places, f.base, f.extractAlgo)
+        if err != nil {
+            return err
+        }
+        report.Spotcheck = "PASS"
+    }
+
+    conversion := time.Now()
+    digits := pi.FormatBase(x, places, f.base)
+    phases := f.phases(opts.Timing, time.Since(conversion))
+    if f.verifyWith != "" {
+        opts.Algorithm = f.verifyWith
+        opts.Timing = nil
+        opts.Checkpoint = ""
+        opts.Trace = nil
+        if err := crossVerify(digits, places, f.algo, opts); err != nil {
+            return err
+        }
+        slog.Info(fmt.Sprintf("%s and %s agree on %d digits", f.algo,
+            f.verifyWith, places), "algorithm", f.algo,
+            "verified_with", f.verifyWith, "digits", places)
+        report.VerifiedWith = f.verifyWith
+    }
+
+    return f.finish(places, f.window(digits), report, start, phases)
+}
+
+// Describe the computation in messages
+func (f *computeFlags) what(places int) string {
+    what := f.constant
+    if f.expr != "" {
+        what = f.expr
+    }
+    switch {
+    case f.timeout > 0 && places < 0:
+        return fmt.Sprintf("as many digits of %s as possible within %s",
+            what, f.timeout)
+    case f.timeout > 0:
+        return fmt.Sprintf("up to %d digits of %s within %s", places, what,
+            f.timeout)
+    case places < 0:
+        places = defaultPlaces
+    }
+    return fmt.Sprintf("%d digits of %s", places, what)
+}
+
+// Return the phase statistics of -stats, nil without, given the timing of
+// the computation and the time of the conversion to digits
+func (f *computeFlags) phases(timing *pi.Timing,
+    conversion time.Duration) *phaseTimes {
+    if !f.stats {
+        return nil
+    }
+    return &phaseTimes{
+        Series:      timing.Series.Seconds(),
+        Combination: timing.Combination.Seconds(),
+        Conversion:  conversion.Seconds(),
+    }
+}
+
+// Write the digits, then the phase statistics and the report, if asked for
+func (f *computeFlags) finish(places int, digits string, report *runReport,
+    start time.Time, phases *phaseTimes) error {
+    output := time.Now()
+    write := func(w io.Writer) error {
+        return f.writeDigits(w, digits)
+    }
+    switch f.format {
+    case "json":
+        result := newJSONResult(places, digits, report, output.Sub(start))
+        write = result.write
+    case "ycd":
+        write = func(w io.Writer) error {
+            return writeYCD(w, digits, f.base)
+        }
+    case "gosrc":
+        write = func(w io.Writer) error {
+            return writeGoSource(w, f.pkg, digits, report)
+        }
+    }
+    if f.shardSize > 0 {
+        if err := f.writeShards(digits, report); err != nil {
+            return err
+        }
+    } else {
+        write = compressed(f.compress, write)
+        if err := writeOutput(f.output, write); err != nil {
+            return err
+        }
+    }
+
+    if phases != nil {
+        phases.Output = time.Since(output).Seconds()
+        report.Phases = phases
+        phases.log()
+    }
+    slog.Debug(fmt.Sprintf("wrote %d digits after %s", places,
+        time.Since(start).Round(time.Millisecond)), "digits", places,
+        "seconds", time.Since(start).Seconds())
+    if f.report != "" {
+        report.finish(places, digits, time.Since(start))
+        return report.write(f.report)
+    }
+    return nil
+}
+
+// Whether the series of the constant and algorithm report their terms
+func (f *computeFlags) traceSupported() bool {
+    if f.constant == "pi" {
+        return f.algo == "machin" || f.algo == "chudnovsky"
+    }
+    return f.constant == "ln2" || f.constant == "ln10"
+}
+
+// Keep the computation within -max-mem: tighten the garbage collector to
+// the limit and refuse if the computation would not fit
+func (f *computeFlags) capMemory(places int, opts *pi.Options) error {
+    debug.SetMemoryLimit(int64(min(f.memLimit, math.MaxInt64)))
+    if places < 0 {
+        if f.timeout > 0 {
+            // No upper limit to predict for
+            return nil
+        }
+        places = defaultPlaces
+    }
+
+    need := pi.EstimateMemory(places, opts)
+    if need > f.memLimit {
+        return fmt.Errorf("%d digits need about %s, more than -max-mem %s",
+            places, formatBytes(need), f.maxMem)
+    }
+    return nil
+}
+
+// Return the number of digits of -extend-to, after checking that there is
+// a checkpoint to extend
+func (f *computeFlags) extension() (int, error) {
+    places, err := parsePlaces(f.extendTo)
+    if err != nil {
+        return 0, err
+    }
+    file, err := os.Open(f.checkpoint)
+    if errors.Is(err, os.ErrNotExist) {
+        return 0, fmt.Errorf("no checkpoint %s to extend, compute the "+
+            "digits with -checkpoint first", f.checkpoint)
+    }
+    if err != nil {
+        return 0, err
+    }
+    defer file.Close()
+    c, err := pi.ReadCheckpoint(bufio.NewReader(file))
+    if err != nil {
+        return 0, fmt.Errorf("%s: %w", f.checkpoint, err)
+    }
+
+    have := c.Digits()
+    if have >= places {
+        slog.Info(fmt.Sprintf("checkpoint %s holds about %d digits, "+
+            "enough for %d", f.checkpoint, have, places),
+            "checkpoint_digits", have, "digits", places)
+    } else {
+        slog.Info(fmt.Sprintf("extending the about %d digits of "+
+            "checkpoint %s to %d", have, f.checkpoint, places),
+            "checkpoint_digits", have, "digits", places)
+    }
+    return places, nil
+}
+
+// Compute bounds instead of pi and print the digits they agree on
+func (f *computeFlags) runCertified(places int, opts *pi.Options,
+    report *runReport, start time.Time) error {
+    if places < 0 {
+        places = defaultPlaces
+    }
+    lo, hi, err := pi.ComputeInterval(context.Background(), places, opts)
+    if err != nil {
+        return err
+    }
+
+    conversion := time.Now()
+    digits, correct := agreeingDigits(pi.FormatBase(lo, places, f.base),
+        pi.FormatBase(hi, places, f.base))
+    phases := f.phases(opts.Timing, time.Since(conversion))
+    if digits == "" {
+        return fmt.Errorf("the bounds do not agree on any digit")
+    }
+    if correct < places {
+        slog.Warn(fmt.Sprintf("the bounds agree on %d of %d digits",
+            correct, places), "certified", correct, "digits", places)
+    }
+    report.Certified = &correct
+
+    return f.finish(correct, f.window(digits), report, start, phases)
+}
+
+// Return the common prefix of two numbers written with the same number of
+// places and how many places it has
+func agreeingDigits(lo, hi string) (string, int) {
+    i := 0
+    for i < len(lo) && i < len(hi) && lo[i] == hi[i] {
+        i++
+    }
+    digits := strings.TrimSuffix(lo[:i], ".")
+    point := strings.IndexByte(digits, '.')
+    if point < 0 {
+        return digits, 0
+    }
+    return digits, len(digits) - point - 1
+}
+
+// Run the spot-check of the last hex digits with the digit extraction
+// formula algo
+func checkTail(x *big.Int, places, base int, algo string) error {
+    check, err := pi.CheckTailAlgo(x, places, base, algo)
+    if err != nil {
+        return err
+    }
+    name := extractName(algo)
+    digits := fmt.Sprintf("hex digits %d-%d", check.Position,
+        check.Position+int64(len(check.BBP))-1)
+    if !check.Passed() {
+        return fmt.Errorf("%s spot-check of %s: FAIL, computed %s, %s %s",
+            name, digits, check.Computed, name, check.BBP)
+    }
+    slog.Info(fmt.Sprintf("%s spot-check of %s: PASS", name, digits),
+        "formula", algo, "position", check.Position, "result", "PASS")
+    return nil
+}
+
+// Return the name of a digit extraction formula in messages
+func extractName(algo string) string {
+    if algo == "bbp" {
+        return "BBP"
+    }
+    return strings.ToUpper(algo[:1]) + algo[1:]
+}
+
+func checkExtractAlgorithm(name string) error {
+    for _, a := range pi.ExtractAlgorithms() {
+        if a == name {
+            return nil
+        }
+    }
+    return usagef("unknown digit extraction formula %q, choose one of %s",
+        name, strings.Join(pi.ExtractAlgorithms(), ", "))
+}
+
+// Recompute pi with the algorithm of opts and compare it to the digits
+// computed by algo
+func crossVerify(digits string, places int, algo string,
+    opts *pi.Options) error {
+    y, err := pi.Compute(context.Background(), places, opts)
+    if err != nil {
+        return err
+    }
+    other := pi.FormatBase(y, places, opts.Base)
+    if other == digits {
+        return nil
+    }
+
+    i := 0
+    for i < len(digits) && i < len(other) && digits[i] == other[i] {
+        i++
+    }
+    // Index 0 and 1 are the leading 3 and the decimal point
+    return fmt.Errorf("%s and %s disagree from digit %d on", algo,
+        opts.Algorithm, i-1)
+}
+
+// Return the number of places given by the -digits flag or as the only
+// argument, -1 if there is none
+func placesArgument(flagValue int, args []string) (int, error) {
+    if len(args) > 1 {
+        return 0, usagef("too many arguments: %s", strings.Join(args, " "))
+    }
+    if len(args) == 0 {
+        if flagValue == -1 {
+            return -1, nil
+        }
+        return parsePlaces(strconv.Itoa(flagValue))
+    }
+    if flagValue != -1 {
+        return 0, usagef("number of digits given twice")
+    }
+    return parsePlaces(args[0])
+}
+
+// Parse a number of digits, a positive integer like 1000 or 1e6
+func parsePlaces(s string) (int, error) {
+    x, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+    switch {
+    case err != nil && !errors.Is(err, strconv.ErrRange),
+        x != math.Trunc(x):
+        return 0, usagef("invalid number of digits %q, need a whole "+
+            "number like 1000 or 1e6", s)
+    case x < 1:
+        return 0, usagef("invalid number of digits %q, need at least 1", s)
+    case x > maxPlaces:
+        return 0, usagef("number of digits %q too large, at most %d", s,
+            maxPlaces)
+    }
+    return int(x), nil
+}
+
+func checkConstant(name string) error {
+    if name == "pi" || pi.ConstantFormula(name, "") != "" {
+        return nil
+    }
+    return usagef("unknown constant %q, choose one of %s", name,
+        strings.Join(pi.Constants(), ", "))
+}
+
+func checkAlgorithm(name string) error {
+    for _, a := range append(pi.Algorithms(), "auto") {
+        if a == name {
+            return nil
+        }
+    }
+    return usagef("unknown algorithm %q, choose one of %s or auto", name,
+        strings.Join(pi.Algorithms(), ", "))
+}
+
+// Call write with a buffered writer on the named file, or stdout if name
+// is empty, and flush it
+func writeOutput(name string, write func(w io.Writer) error) error {
+    out := os.Stdout
+    if name != "" {
+        f, err := os.Create(name)
+        if err != nil {
+            return err
+        }
+        defer f.Close()
+        out = f
+    }
+
+    w := bufio.NewWriter(out)
+    if err := write(w); err != nil {
+        return err
+    }
+    if err := w.Flush(); err != nil {
+        return err
+    }
+    if out != os.Stdout {
+        return out.Close()
+    }
+    return nil
+}
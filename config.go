@@ -0,0 +1,235 @@
+// Defaults for the flags from the environment and a configuration file.
+//
+// A flag not given on the command line takes its value from the variable
+// PI_<FLAG>, upper case with underscores, e.g. PI_ALGO or PI_MAX_MEM, and
+// else from the configuration file: $PI_CONFIG, by default config.toml in
+// $XDG_CONFIG_HOME/pi or ~/.config/pi. The file is a small subset of TOML,
+// keys with strings, numbers or booleans as values. Keys at the top apply
+// to every command with such a flag, and some command must have it; keys
+// in a [command] section apply to that command only. Numbers of digits
+// may be written like 1e6, as on the command line:
+//
+//    digits = 10000
+//    algo = "chudnovsky"
+//
+//    [serve]
+//    addr = ":8080"
+
+package main
+
+import (
+    "bufio"
+    "flag"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+)
+
+// The settings of a configuration file by section, "" for the top
+type config map[string]map[string]string
+
+// Return the path of the configuration file, "" if there is none
+func configPath() string {
+    if name, ok := os.LookupEnv("PI_CONFIG"); ok {
+        return name
+    }
+    dir := os.Getenv("XDG_CONFIG_HOME")
+    if dir == "" {
+        home, err := os.UserHomeDir()
+        if err != nil {
+            return ""
+        }
+        dir = filepath.Join(home, ".config")
+    }
+    return filepath.Join(dir, "pi", "config.toml")
+}
+
+// Read the named configuration file, an empty one if it does not exist
+func readConfig(name string) (config, error) {
+    c := config{"": {}}
+    if name == "" {
+        return c, nil
+    }
+    file, err := os.Open(name)
+    if os.IsNotExist(err) {
+        return c, nil
+    } else if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    section := ""
+    s := bufio.NewScanner(file)
+    for n := 1; s.Scan(); n++ {
+        line := strings.TrimSpace(s.Text())
+        switch {
+        case line == "" || line[0] == '#':
+            continue
+        case line[0] == '[':
+            if !strings.HasSuffix(line, "]") {
+                return nil, fmt.Errorf("%s:%d: invalid section %s", name, n,
+                    line)
+            }
+            section = strings.TrimSpace(line[1 : len(line)-1])
+            if lookupCommand(section) == nil {
+                return nil, fmt.Errorf("%s:%d: unknown command %q", name, n,
+                    section)
+            }
+            if c[section] == nil {
+                c[section] = map[string]string{}
+            }
+            continue
+        }
+
+        key, value, ok := strings.Cut(line, "=")
+        if !ok {
+            return nil, fmt.Errorf("%s:%d: missing = in %q", name, n, line)
+        }
+        key = strings.ReplaceAll(strings.TrimSpace(key), "_", "-")
+        value, err := configValue(strings.TrimSpace(value))
+        if err != nil {
+            return nil, fmt.Errorf("%s:%d: %s: %v", name, n, key, err)
+        }
+        c[section][key] = value
+    }
+    return c, s.Err()
+}
+
+// Return the TOML value as flag value: strings unquoted, numbers and
+// booleans as they are
+func configValue(value string) (string, error) {
+    if value == "" {
+        return "", fmt.Errorf("missing value")
+    }
+    switch value[0] {
+    case '"':
+        end := strings.LastIndexByte(value, '"')
+        if end == 0 {
+            return "", fmt.Errorf("unterminated string %s", value)
+        }
+        if err := checkComment(value[end+1:]); err != nil {
+            return "", err
+        }
+        return strconv.Unquote(value[:end+1])
+    case '\'':
+        end := strings.LastIndexByte(value, '\'')
+        if end == 0 {
+            return "", fmt.Errorf("unterminated string %s", value)
+        }
+        if err := checkComment(value[end+1:]); err != nil {
+            return "", err
+        }
+        return value[1:end], nil
+    case '[', '{':
+        return "", fmt.Errorf("arrays and tables are not supported")
+    }
+
+    if i := strings.IndexByte(value, '#'); i >= 0 {
+        value = strings.TrimSpace(value[:i])
+    }
+    // Underscores may separate the digits of numbers
+    return strings.ReplaceAll(value, "_", ""), nil
+}
+
+// Only a comment may follow a value
+func checkComment(rest string) error {
+    rest = strings.TrimSpace(rest)
+    if rest != "" && rest[0] != '#' {
+        return fmt.Errorf("unexpected %q after the value", rest)
+    }
+    return nil
+}
+
+// Set the flags not given on the command line from the environment and
+// the configuration file
+func (cmd *command) configure(fs *flag.FlagSet) error {
+    given := map[string]bool{}
+    fs.Visit(func(f *flag.Flag) {
+        given[flagName(f.Name)] = true
+    })
+    // The number of digits as argument counts as -digits
+    if cmd.args == "[digits]" && fs.NArg() > 0 {
+        given["digits"] = true
+    }
+
+    c, err := readConfig(configPath())
+    if err != nil {
+        return err
+    }
+    for key := range c[cmd.name] {
+        if fs.Lookup(key) == nil {
+            return fmt.Errorf("%s has no flag -%s in the configuration",
+                cmd.name, key)
+        }
+    }
+    for key := range c[""] {
+        if !anyCommandFlag(key) {
+            return fmt.Errorf("no command has a flag -%s in the "+
+                "configuration", key)
+        }
+    }
+
+    fs.VisitAll(func(f *flag.Flag) {
+        if err != nil || given[flagName(f.Name)] {
+            return
+        }
+        env := "PI_" + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+        source := env
+        value, ok := os.LookupEnv(env)
+        if !ok {
+            source = "the configuration"
+            value, ok = c[cmd.name][f.Name]
+        }
+        if !ok {
+            value, ok = c[""][f.Name]
+        }
+        if !ok {
+            return
+        }
+        // Numbers of digits may be written like 1e6, as on the command line
+        if n, perr := parsePlaces(value); perr == nil && isIntFlag(f) &&
+            strings.HasSuffix(f.Name, "digits") {
+            value = strconv.Itoa(n)
+        }
+        if serr := fs.Set(f.Name, value); serr != nil {
+            err = usagef("invalid value %q for -%s from %s: %v", value,
+                f.Name, source, serr)
+        }
+        // The other name of the flag is set now as well
+        given[flagName(f.Name)] = true
+    })
+    return err
+}
+
+// Report whether any command has a flag of the given name
+func anyCommandFlag(name string) bool {
+    for _, c := range commands() {
+        if fs, _ := c.flagSet("pi"); fs.Lookup(name) != nil {
+            return true
+        }
+    }
+    return false
+}
+
+// Report whether the flag holds an int
+func isIntFlag(f *flag.Flag) bool {
+    g, ok := f.Value.(flag.Getter)
+    if !ok {
+        return false
+    }
+    _, ok = g.Get().(int)
+    return ok
+}
+
+// The long names of flags that have a short one too
+var flagAliases = map[string]string{"parallelism": "j"}
+
+// Return the name of the flag, the short one of a flag with two
+func flagName(name string) string {
+    if short, ok := flagAliases[name]; ok {
+        return short
+    }
+    return name
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+// Return the flags of the command configured from the given file
+func configureFlags(t *testing.T, command, file string) (
+    func(name string) string, error) {
+    name := filepath.Join(t.TempDir(), "config.toml")
+    if err := os.WriteFile(name, []byte(file), 0644); err != nil {
+        t.Fatal(err)
+    }
+    t.Setenv("PI_CONFIG", name)
+    cmd := lookupCommand(command)
+    fs, _ := cmd.flagSet("pi_by_digits")
+    if err := fs.Parse(nil); err != nil {
+        t.Fatal(err)
+    }
+    err := cmd.configure(fs)
+    return func(name string) string {
+        return fs.Lookup(name).Value.String()
+    }, err
+}
+
+func TestConfigure(t *testing.T) {
+    flags, err := configureFlags(t, "compute", "digits = 1e3\n")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := flags("digits"); got != "1000" {
+        t.Errorf("digits = 1e3 in the file: -digits %s", got)
+    }
+
+    t.Setenv("PI_DIGITS", "1e6")
+    flags, err = configureFlags(t, "compute", "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := flags("digits"); got != "1000000" {
+        t.Errorf("PI_DIGITS=1e6: -digits %s", got)
+    }
+
+    // A key at the top that no command defines
+    _, err = configureFlags(t, "compute", "digitz = 5\n")
+    if err == nil || !strings.Contains(err.Error(), "-digitz") {
+        t.Errorf("digitz = 5 in the file: %v", err)
+    }
+}
@@ -0,0 +1,117 @@
+// The diff command: compare two digit files digit by digit, streaming both,
+// and report the first difference and the length of the common prefix.
+//
+// The files may be of any kind a digit file can be, see digitfile.go:
+// plain text, compressed with gzip or zstd, y-cruncher's .ycd or the
+// manifest of sharded output. The exit status is 1 if they differ.
+
+package main
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "strings"
+)
+
+var diffCommand = &command{
+    name:  "diff",
+    args:  "a.txt b.txt [flags]",
+    short: "report where two digit files first differ",
+    setup: setupDiff,
+}
+
+func setupDiff(fs *flag.FlagSet) func(args []string) error {
+    prefix := fs.Bool("prefix", false,
+        "succeed if one file ends early but matches the other up to there")
+
+    return func(args []string) error {
+        if len(args) < 2 {
+            return usagef("need two digit files")
+        }
+        // The flags may follow the files as well
+        if err := fs.Parse(args[2:]); err != nil {
+            return err
+        }
+        if fs.NArg() > 0 {
+            return usagef("unexpected arguments: %s",
+                strings.Join(fs.Args(), " "))
+        }
+        a, ac, err := openDigitFile(args[0])
+        if err != nil {
+            return err
+        }
+        defer ac.Close()
+        b, bc, err := openDigitFile(args[1])
+        if err != nil {
+            return err
+        }
+        defer bc.Close()
+        if err := sameBase(a, b); err != nil {
+            return err
+        }
+
+        d, err := diffDigits(a, b)
+        if err != nil {
+            return err
+        }
+        switch {
+        case d.differ:
+            fmt.Printf("first difference at digit %d: %c in %s, %c in %s\n"+
+                "%d digits match\n", d.matching+1, d.a, a.name, d.b, b.name,
+                d.matching)
+            return errors.New("the files differ")
+        case d.short != nil:
+            long := a
+            if d.short == a {
+                long = b
+            }
+            fmt.Printf("%d digits match, %s ends there, %s goes on\n",
+                d.matching, d.short.name, long.name)
+            if *prefix {
+                return nil
+            }
+            return errors.New("the files differ in length")
+        }
+        fmt.Printf("all %d digits match\n", d.matching)
+        return nil
+    }
+}
+
+// Where two digit streams part
+type digitDiff struct {
+    matching int64 // length of the common prefix
+    differ   bool  // whether a digit differs, a and b are the digits
+    a, b     byte
+    short    *digitReader // the stream ending first, nil for the same length
+}
+
+// Read both streams up to their first difference or the end of the shorter
+func diffDigits(a, b *digitReader) (*digitDiff, error) {
+    d := &digitDiff{}
+    for {
+        x, aerr := a.next()
+        if aerr != nil && !errors.Is(aerr, io.EOF) {
+            return nil, aerr
+        }
+        y, berr := b.next()
+        if berr != nil && !errors.Is(berr, io.EOF) {
+            return nil, berr
+        }
+        switch {
+        case aerr != nil && berr != nil:
+            return d, nil
+        case aerr != nil:
+            d.short = a
+            return d, nil
+        case berr != nil:
+            d.short = b
+            return d, nil
+        case x != y:
+            d.differ, d.a, d.b = true, x, y
+            return d, nil
+        }
+        d.matching++
+    }
+}
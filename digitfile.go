@@ -0,0 +1,209 @@
+// Reading digit files.
+//
+// A digit file holds the decimal places of a constant as text, optionally
+// preceded by its integer part and the point, like "3." for pi or "2." for
+// e. Whitespace, e.g. line breaks, is ignored, and so are the places in
+// front of the lines of compute -number-lines. Text files hold decimal
+// digits, y-cruncher's files and the manifests tell their base. Files
+// compressed with gzip or zstd, the compressed digit files of y-cruncher
+// and the manifests of sharded output are recognized and read as well.
+
+package main
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+type digitReader struct {
+    name    string
+    base    int // of the digits, 2 to 36
+    r       *bufio.Reader
+    places  int64 // decimal places read so far
+    total   int64 // decimal places the file declares, 0 if unknown
+    size    int64 // at most this many places, 0 if unknown
+    started bool
+    newline bool // at the start of a line
+}
+
+func newDigitReader(name string, r io.Reader) *digitReader {
+    return &digitReader{name: name, base: 10,
+        r: bufio.NewReaderSize(r, 1<<16)}
+}
+
+// Open the named digit file, the caller closes the returned closer
+func openDigitFile(name string) (*digitReader, io.Closer, error) {
+    if strings.HasSuffix(name, shardManifestSuffix) {
+        return openShards(name)
+    }
+    r, f, compressed, err := openDecompressed(name)
+    if err != nil {
+        return nil, nil, err
+    }
+    if magic, _ := r.Peek(len(ycdMagic)); string(magic) == ycdMagic {
+        ycd, err := newYCDReader(r)
+        if err != nil {
+            f.Close()
+            return nil, nil, fmt.Errorf("%s: %v", name, err)
+        }
+        d := newDigitReader(name, ycd)
+        d.base = ycd.base
+        d.total = ycd.places
+        return d, f, nil
+    }
+    d := newDigitReader(name, r)
+    if info, err := os.Stat(name); err == nil && !compressed {
+        // A text file holds at most one digit per byte
+        d.size = info.Size()
+    }
+    return d, f, nil
+}
+
+// Return the next decimal place as ASCII digit, io.EOF at the end
+func (d *digitReader) next() (byte, error) {
+    if !d.started {
+        d.started = true
+        d.skipIntegerPart()
+        d.newline = true
+    }
+    for {
+        if d.newline {
+            d.newline = false
+            if err := d.skipLabel(); err != nil {
+                return 0, err
+            }
+        }
+        c, err := d.r.ReadByte()
+        if errors.Is(err, io.EOF) {
+            return 0, io.EOF
+        }
+        if err != nil {
+            // Mostly a damaged compressed file
+            return 0, fmt.Errorf("%s: %v", d.name, err)
+        }
+
+        switch {
+        case c == '\n':
+            d.newline = true
+            continue
+        case c == ' ' || c == '\t' || c == '\r':
+            continue
+        case !d.isDigit(c):
+            return 0, fmt.Errorf("%s: invalid character %q after %d digits",
+                d.name, c, d.places)
+        }
+
+        d.places++
+        return c, nil
+    }
+}
+
+// Report whether c is a digit of the base of the file, lower case beyond 9
+func (d *digitReader) isDigit(c byte) bool {
+    switch {
+    case c >= '0' && c <= '9':
+        return int(c-'0') < d.base
+    case c >= 'a' && c <= 'z':
+        return int(c-'a')+10 < d.base
+    }
+    return false
+}
+
+// Return an error unless both readers have digits of the same base
+func sameBase(a, b *digitReader) error {
+    if a.base != b.base {
+        return fmt.Errorf("%s holds digits of base %d, %s of base %d",
+            a.name, a.base, b.name, b.base)
+    }
+    return nil
+}
+
+// Skip the integer part and the decimal point, if the file starts with
+// them after any whitespace
+func (d *digitReader) skipIntegerPart() {
+    digits := false
+    for n := 1; n <= d.r.Size(); n++ {
+        p, _ := d.r.Peek(n)
+        if len(p) < n {
+            return
+        }
+        switch c := p[n-1]; {
+        case c == '.' && digits:
+            d.r.Discard(n)
+            return
+        case c >= '0' && c <= '9':
+            digits = true
+        case digits || c != ' ' && c != '\t' && c != '\n' && c != '\r':
+            return
+        }
+    }
+}
+
+// Skip the label of a line written by -number-lines, the place of its
+// first digit, aligned to the right and followed by two spaces
+func (d *digitReader) skipLabel() error {
+    var label int64
+    digits := 0
+    for n := 0; digits < 19; n++ {
+        p, _ := d.r.Peek(n + 2)
+        if len(p) < n+2 {
+            return nil
+        }
+        switch c := p[n]; {
+        case c == ' ' && digits == 0:
+        case c >= '0' && c <= '9':
+            label = 10*label + int64(c-'0')
+            digits++
+        case c == ' ' && p[n+1] == ' ' && digits > 0:
+            if label != d.places+1 {
+                return fmt.Errorf("%s: line labeled %d at place %d",
+                    d.name, label, d.places+1)
+            }
+            d.r.Discard(n + 2)
+            return nil
+        default:
+            return nil
+        }
+    }
+    return nil
+}
+
+// Return a reader of pi with the given number of places in the given base
+func computedDigits(places, base int) (*digitReader, error) {
+    if base == 10 {
+        return newDigitReader("computed digits", pi.NewReader(places)), nil
+    }
+    x, err := pi.Compute(context.Background(), places,
+        &pi.Options{Base: base})
+    if err != nil {
+        return nil, err
+    }
+    d := newDigitReader("computed digits",
+        strings.NewReader(pi.FormatBase(x, places, base)))
+    d.base = base
+    return d, nil
+}
+
+// Open the decimal digits a command works on: the named digit file, or if
+// name is empty the given number of computed digits. The caller closes the
+// returned closer.
+func openDigits(name string, places int) (*digitReader, io.Closer, error) {
+    if name != "" {
+        d, c, err := openDigitFile(name)
+        if err == nil && d.base != 10 {
+            c.Close()
+            return nil, nil, fmt.Errorf("%s holds digits of base %d, "+
+                "not decimal ones", name, d.base)
+        }
+        return d, c, err
+    }
+    return newDigitReader("computed digits", pi.NewReader(places)),
+        io.NopCloser(nil), nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestRawRoundTrip(t *testing.T) {
+    name := filepath.Join(t.TempDir(), "raw.txt")
+    if err := runCommand(t, "compute", "-raw", "-output", name,
+        "1000"); err != nil {
+        t.Fatal(err)
+    }
+    raw, err := os.ReadFile(name)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(raw) != 1000 || !strings.HasPrefix(string(raw), "1415926535") {
+        t.Fatalf("-raw wrote %d bytes starting with %.12q", len(raw), raw)
+    }
+    if err := runCommand(t, "verify", "-file", name); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestDiffConstants(t *testing.T) {
+    dir := t.TempDir()
+    for _, constant := range []string{"e", "sqrt:10005"} {
+        name := filepath.Join(dir, "c.txt")
+        if err := runCommand(t, "compute", "-constant", constant,
+            "-output", name, "500"); err != nil {
+            t.Fatal(err)
+        }
+        shards := filepath.Join(dir, "s.txt")
+        if err := runCommand(t, "compute", "-constant", constant,
+            "-shard-size", "200", "-output", shards, "500"); err != nil {
+            t.Fatal(err)
+        }
+        manifest := shards + shardManifestSuffix
+        for _, b := range []string{name, manifest} {
+            if err := runCommand(t, "diff", name, b); err != nil {
+                t.Fatalf("%s: %v", constant, err)
+            }
+        }
+
+        // The places only, without the integer part
+        d, c, err := openDigitFile(manifest)
+        if err != nil {
+            t.Fatal(err)
+        }
+        first, err := d.next()
+        c.Close()
+        want := map[string]byte{"e": '7', "sqrt:10005": '0'}[constant]
+        if err != nil || first != want {
+            t.Fatalf("%s: first place %q, %v", constant, first, err)
+        }
+    }
+}
+
+func TestNumberLinesRoundTrip(t *testing.T) {
+    dir := t.TempDir()
+    for _, layout := range [][]string{
+        {"-line", "50"},
+        {"-group", "10", "-line", "50"},
+        {"-group", "5", "-line", "64"},
+    } {
+        name := filepath.Join(dir, "lines.txt")
+        args := append([]string{"compute", "-number-lines",
+            "-output", name}, layout...)
+        if err := runCommand(t, append(args, "1000")...); err != nil {
+            t.Fatal(err)
+        }
+        if err := runCommand(t, "verify", "-file", name); err != nil {
+            t.Fatalf("%v: %v", layout, err)
+        }
+    }
+
+    // Labels not matching the places are an error, not digits
+    name := filepath.Join(dir, "bad.txt")
+    os.WriteFile(name, []byte("3.\n 1  14159\n 7  26535\n"), 0644)
+    err := runCommand(t, "verify", "-file", name)
+    if err == nil || !strings.Contains(err.Error(), "labeled 7 at place 6") {
+        t.Fatalf("mislabeled line: %v", err)
+    }
+}
+
+func TestYCDRoundTrip(t *testing.T) {
+    dir := t.TempDir()
+    text := filepath.Join(dir, "pi.txt")
+    for _, base := range []string{"10", "16"} {
+        // Not a multiple of the digits per word, the last one is padded
+        name := filepath.Join(dir, "pi"+base+".ycd")
+        if err := runCommand(t, "compute", "-format", "ycd", "-base", base,
+            "-output", name, "1001"); err != nil {
+            t.Fatal(err)
+        }
+        if err := runCommand(t, "verify", "-file", name); err != nil {
+            t.Fatalf("base %s: %v", base, err)
+        }
+        if err := runCommand(t, "compute", "-base", base, "-output", text,
+            "1001"); err != nil {
+            t.Fatal(err)
+        }
+        if base == "16" {
+            // A text file holds decimal digits
+            err := runCommand(t, "diff", name, text)
+            if err == nil || !strings.Contains(err.Error(), "base 16") {
+                t.Fatalf("diff of base 16 and text: %v", err)
+            }
+            continue
+        }
+        if err := runCommand(t, "diff", name, text); err != nil {
+            t.Fatalf("base %s: %v", base, err)
+        }
+    }
+    if err := runCommand(t, "diff", filepath.Join(dir, "pi16.ycd"),
+        filepath.Join(dir, "pi16.ycd")); err != nil {
+        t.Fatal(err)
+    }
+}
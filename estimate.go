@@ -0,0 +1,82 @@
+// The -estimate flag of compute: predict the cost instead of computing.
+
+package main
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+// Print the predicted cost of computing the given number of places
+func runEstimate(places int, opts *pi.Options) error {
+    cost, err := pi.Estimate(context.Background(), places, opts)
+    if err != nil {
+        return err
+    }
+    fmt.Printf("peak memory  about %s\n", formatBytes(cost.Memory))
+    fmt.Printf("time         about %s\n", roundDuration(cost.Time))
+    return nil
+}
+
+// Return n in bytes with a binary unit, e.g. "1.5 GiB"
+func formatBytes(n uint64) string {
+    const units = "KMGTPE"
+    if n < 1024 {
+        return fmt.Sprintf("%d B", n)
+    }
+    x := float64(n) / 1024
+    i := 0
+    for x >= 1024 && i < len(units)-1 {
+        x /= 1024
+        i++
+    }
+    return fmt.Sprintf("%.1f %ciB", x, units[i])
+}
+
+// Parse a number of bytes with an optional unit: B, the binary KiB, MiB,
+// GiB and TiB or the decimal kB, MB, GB and TB
+func parseBytes(s string) (uint64, error) {
+    units := []struct {
+        suffix string
+        scale  float64
+    }{
+        {"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+        {"TiB", 1 << 40}, {"kB", 1e3}, {"KB", 1e3}, {"MB", 1e6},
+        {"GB", 1e9}, {"TB", 1e12}, {"B", 1},
+    }
+    scale := 1.0
+    for _, u := range units {
+        if strings.HasSuffix(s, u.suffix) {
+            s, scale = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)),
+                u.scale
+            break
+        }
+    }
+    x, err := strconv.ParseFloat(s, 64)
+    if err != nil || x < 0 || x*scale >= math.MaxUint64 {
+        return 0, fmt.Errorf("invalid number of bytes %q", s)
+    }
+    return uint64(x * scale), nil
+}
+
+// Return d rounded to two or three significant digits for printing
+func roundDuration(d time.Duration) time.Duration {
+    switch {
+    case d >= time.Hour:
+        return d.Round(time.Minute)
+    case d >= time.Minute:
+        return d.Round(time.Second)
+    case d >= time.Second:
+        return d.Round(10 * time.Millisecond)
+    case d >= time.Millisecond:
+        return d.Round(time.Millisecond)
+    default:
+        return d.Round(time.Microsecond)
+    }
+}
@@ -0,0 +1,84 @@
+// The eval command: a calculator on the constants, printing an expression
+// like "2*pi + e^2" to any number of digits.
+//
+// The expression is that of compute -expr, see package pi: every constant
+// in it is computed once with the guard digits the error bounds of the
+// whole expression ask for, and the last digit is rounded correctly, i.e.
+// the digits are those of the exact value rounded to nearest.
+
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "log/slog"
+    "strings"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+var evalCommand = &command{
+    name:  "eval",
+    args:  "expression [flags]",
+    short: "print an expression in pi and the other constants, rounded",
+    setup: setupEval,
+}
+
+func setupEval(fs *flag.FlagSet) func(args []string) error {
+    digits := fs.String("digits", "50",
+        "number of digits after the point, e.g. 1000 or 1e6")
+    base := fs.Int("base", 10, "write the digits in this base, 2 to 36")
+    algo := fs.String("algo", pi.DefaultAlgorithm,
+        "algorithm for pi: "+strings.Join(pi.Algorithms(), ", ")+" or auto")
+    truncate := fs.Bool("truncate", false,
+        "truncate the last digit instead of rounding")
+
+    return func(args []string) error {
+        if len(args) == 0 {
+            return usagef("missing expression, e.g. \"2*pi + e^2\"")
+        }
+        // The flags may follow the expression as well
+        expr := args[0]
+        if err := fs.Parse(args[1:]); err != nil {
+            return err
+        }
+        if fs.NArg() > 0 {
+            return usagef("one expression only, quote it: %s",
+                strings.Join(args, " "))
+        }
+
+        places, err := parsePlaces(*digits)
+        if err != nil {
+            return err
+        }
+        if *base < 2 || *base > 36 {
+            return usagef("invalid base %d, choose one from 2 to 36", *base)
+        }
+        if err := checkAlgorithm(*algo); err != nil {
+            return err
+        }
+        if err := pi.CheckExpr(expr); err != nil {
+            return usagef("%s", strings.TrimPrefix(err.Error(), "pi: "))
+        }
+
+        opts := &pi.Options{
+            Expr:      expr,
+            Algorithm: *algo,
+            Base:      *base,
+            Round:     !*truncate,
+        }
+        x, correct, err := pi.ComputeCertified(context.Background(), places,
+            opts)
+        if err != nil {
+            return err
+        }
+        if correct < places {
+            slog.Warn(fmt.Sprintf("only %d of %d digits are certified "+
+                "correct by the error bound", correct, places),
+                "certified", correct, "digits", places)
+        }
+        fmt.Println(pi.FormatBase(x, places, *base))
+        return nil
+    }
+}
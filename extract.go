@@ -0,0 +1,69 @@
+// The extract command: hex or decimal digits of pi at a position, without
+// the digits before it.
+
+package main
+
+import (
+    "flag"
+    "fmt"
+    "strings"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+var extractCommand = &command{
+    name:  "extract",
+    args:  "",
+    short: "print hex or decimal digits of pi at a position by extraction",
+    setup: setupExtract,
+}
+
+func setupExtract(fs *flag.FlagSet) func(args []string) error {
+    position := fs.Int64("position", 1,
+        "position of the first digit, 1 for the first after the point")
+    count := fs.Int("count", 16,
+        fmt.Sprintf("number of digits, at most %d hex or %d decimal",
+            pi.MaxHexDigits, pi.MaxDecimalDigits))
+    base := fs.Int("base", 16,
+        "16, or 10 for decimal digits by Plouffe's formula, which takes\n"+
+            "time growing with the square of the position")
+    algo := fs.String("extract-algo", pi.ExtractAlgorithms()[0],
+        "hex digit extraction formula: "+
+            strings.Join(pi.ExtractAlgorithms(), ", "))
+
+    return func(args []string) error {
+        if len(args) > 0 {
+            return usagef("unexpected arguments")
+        }
+        if *position < 1 {
+            return usagef("invalid position %d", *position)
+        }
+        limit := pi.MaxHexDigits
+        switch *base {
+        case 16:
+        case 10:
+            limit = pi.MaxDecimalDigits
+        default:
+            return usagef("invalid base %d, choose 16 or 10", *base)
+        }
+        if *count < 1 || *count > limit {
+            return usagef("invalid count %d, choose 1 to %d", *count, limit)
+        }
+        if err := checkExtractAlgorithm(*algo); err != nil {
+            return err
+        }
+
+        var digits string
+        var err error
+        if *base == 10 {
+            digits, err = pi.DecimalDigits(*position, *count)
+        } else {
+            digits, err = pi.HexDigitsAlgo(*position, *count, *algo)
+        }
+        if err != nil {
+            return err
+        }
+        fmt.Println(digits)
+        return nil
+    }
+}
@@ -0,0 +1,164 @@
+// The find command: where does my number occur in pi?
+//
+// Unlike search, find stops at the first occurrence and shows it within
+// the digits around it. Dates and phone numbers may be written with
+// separators, which are dropped. The digits are computed in rounds of ten
+// times as many as before, most numbers being found long before the limit.
+
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "log/slog"
+    "strings"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+const (
+    // Digits of the first round of find
+    findStartPlaces = 10000
+
+    defaultFindPlaces = 10000000
+)
+
+var findCommand = &command{
+    name:  "find",
+    args:  "number [flags]",
+    short: "show where a number, e.g. a birthday, first occurs in pi",
+    setup: setupFind,
+}
+
+func setupFind(fs *flag.FlagSet) func(args []string) error {
+    limit := fs.Int("max", defaultFindPlaces,
+        "look within this many digits after the point")
+    around := fs.Int("context", 10, "show this many digits on either side")
+    file := fs.String("file", "", "look in this digit file instead")
+    algo := fs.String("algo", "chudnovsky",
+        "algorithm computing the digits: "+strings.Join(pi.Algorithms(), ", "))
+
+    return func(args []string) error {
+        if len(args) == 0 {
+            return usagef("expected exactly one number")
+        }
+        // The flags may follow the number as well
+        if err := fs.Parse(args[1:]); err != nil {
+            return err
+        }
+        if fs.NArg() > 0 {
+            return usagef("expected exactly one number")
+        }
+        number, err := findPattern(args[0])
+        if err != nil {
+            return err
+        }
+        if *limit < len(number) || *around < 0 {
+            return usagef("invalid -max %d or -context %d", *limit, *around)
+        }
+        if err := checkAlgorithm(*algo); err != nil {
+            return err
+        }
+
+        var digits string
+        var place int
+        if *file != "" {
+            digits, err = readDigitFile(*file)
+            if err != nil {
+                return err
+            }
+            place = strings.Index(digits, number) + 1
+            *limit = len(digits)
+        } else {
+            digits, place, err = findComputed(number, *limit, *around, *algo)
+            if err != nil {
+                return err
+            }
+        }
+        if place == 0 {
+            fmt.Printf("%s is not found within %d digits of pi, try a "+
+                "larger -max.\n", number, *limit)
+            return nil
+        }
+
+        start, end := place-1, place-1+len(number)
+        fmt.Printf("%s first occurs at place %d after the decimal point:"+
+            "\n\n", number, place)
+        fmt.Printf("    %s[%s]%s\n\n", digits[max(0, start-*around):start],
+            number, digits[end:min(len(digits), end+*around)])
+        if place <= 50 {
+            fmt.Println("That is right at the start: 3." +
+                digits[:end] + "...")
+        } else {
+            fmt.Printf("You would have to write down %d digits of pi to "+
+                "get there.\n", end)
+        }
+        return nil
+    }
+}
+
+// Return the digits of the number, without separators like . / - or spaces
+func findPattern(arg string) (string, error) {
+    var b strings.Builder
+    for _, c := range arg {
+        switch {
+        case c >= '0' && c <= '9':
+            b.WriteRune(c)
+        case strings.ContainsRune(" ./-:,()+", c):
+        default:
+            return "", usagef("invalid number %q, only digits and "+
+                "separators like . / - are allowed", arg)
+        }
+    }
+    if b.Len() == 0 {
+        return "", usagef("no digits in %q", arg)
+    }
+    return b.String(), nil
+}
+
+// Compute rounds of more and more digits, up to limit, until the number
+// occurs. Return the digits after the point, including the context
+// following the occurrence, and its 1-based place, 0 if not found.
+func findComputed(number string, limit, around int,
+    algo string) (string, int, error) {
+    opts := &pi.Options{Algorithm: algo}
+    places := min(findStartPlaces, limit)
+    for {
+        if places >= 1000000 {
+            slog.Info(fmt.Sprintf("looking within %d digits...", places),
+                "digits", places)
+        }
+        n := places + around
+        x, err := pi.Compute(context.Background(), n, opts)
+        if err != nil {
+            return "", 0, err
+        }
+        digits := pi.Format(x, n)[2:]
+        i := strings.Index(digits, number)
+        if i >= 0 && i+len(number) <= places {
+            return digits, i + 1, nil
+        }
+        if places == limit {
+            return "", 0, nil
+        }
+        places = min(10*places, limit)
+    }
+}
+
+// Return the digits after the point of the digit file
+func readDigitFile(name string) (string, error) {
+    r, f, err := openDigits(name, 0)
+    if err != nil {
+        return "", err
+    }
+    defer f.Close()
+    digits, err := readAllDigits(r)
+    if err != nil {
+        return "", err
+    }
+    for i := range digits {
+        digits[i] += '0'
+    }
+    return string(digits), nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestFind(t *testing.T) {
+    for _, c := range []struct {
+        args []string
+        want string
+    }{
+        // Flags after the number, as in find 14032001 --max 100000000
+        {[]string{"find", "59-26-53", "--max", "100000", "-context", "3"},
+            "    141[592653]589\n"},
+        {[]string{"find", "99999999", "--max", "1000"},
+            "99999999 is not found within 1000 digits of pi"},
+    } {
+        out, err := runOutput(t, c.args...)
+        if err != nil {
+            t.Fatalf("%s: %v", strings.Join(c.args, " "), err)
+        }
+        if !strings.Contains(out, c.want) {
+            t.Fatalf("%s: got %q, want %q", strings.Join(c.args, " "), out,
+                c.want)
+        }
+    }
+}
@@ -0,0 +1,94 @@
+// Go source embedding the digits, for -format gosrc.
+//
+// The digits become a string constant concatenated from lines of
+// gosrcLine digits, long lines being slow to handle for the compiler and
+// editors alike. The file is formatted as gofmt would.
+
+package main
+
+import (
+    "fmt"
+    "io"
+    "strings"
+    "unicode"
+)
+
+// Digits per line of the constant
+const gosrcLine = 64
+
+// The file up to the first line of the constant
+const gosrcHeader = `// Code generated by pi_by_digits compute; DO NOT EDIT.
+
+// Package %s holds %s with %d digits after the point.
+package %s
+
+// Places is the number of digits of %s after the point.
+const Places = %d
+
+// %s is %s in base %d%s.
+const %s = `
+
+// Return the name of the Go constant holding the digits: the constant's
+// name capitalized, "Value" for an expression
+func gosrcName(constant, expr string) string {
+    if expr != "" {
+        return "Value"
+    }
+    var name []rune
+    upper := true
+    for _, r := range constant {
+        switch {
+        case unicode.IsLetter(r) || unicode.IsDigit(r):
+            if upper {
+                r = unicode.ToUpper(r)
+            }
+            name = append(name, r)
+            upper = false
+        default:
+            upper = true
+        }
+    }
+    return string(name)
+}
+
+// Write the digits as Go source file of the named package
+func writeGoSource(w io.Writer, pkg string, digits string,
+    report *runReport) error {
+    what := report.Constant
+    if report.Expr != "" {
+        what = report.Expr
+    }
+    name := gosrcName(report.Constant, report.Expr)
+    places := 0
+    if point := strings.IndexByte(digits, '.'); point >= 0 {
+        places = len(digits) - point - 1
+    }
+    how := ""
+    if report.Algorithm != "" {
+        how = ", computed with " + report.Algorithm
+    }
+
+    header := fmt.Sprintf(gosrcHeader, pkg, what, places, pkg, name,
+        places, name, what, report.Base, how, name)
+    if _, err := io.WriteString(w, header); err != nil {
+        return err
+    }
+
+    for first := true; first || len(digits) > 0; first = false {
+        n := min(len(digits), gosrcLine)
+        sep := ""
+        if n < len(digits) {
+            sep = " +"
+        }
+        indent := "\t"
+        if first {
+            indent = ""
+        }
+        if _, err := fmt.Fprintf(w, "%s%q%s\n", indent, digits[:n],
+            sep); err != nil {
+            return err
+        }
+        digits = digits[n:]
+    }
+    return nil
+}
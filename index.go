@@ -0,0 +1,475 @@
+// The index and query commands: a persistent k-gram index over a digit file
+// for repeated searches.
+//
+// Index file layout, all integers little endian:
+//
+//    header    magic "PIDIGIDX", version, k, position width in bytes (4 or
+//              8), reserved, all uint32, and the number of digits, uint64
+//    digits    the digits with two digits per byte, the first one in the
+//              high nibble
+//    table     10**k + 1 uint64: the index into positions of the first
+//              position of every k-gram, in numerical order of the k-grams,
+//              plus the total
+//    positions the 1-based positions of all k-grams, bucket by bucket and
+//              ascending within a bucket
+//
+// A pattern shorter than k matches a contiguous range of buckets, a longer
+// one is looked up with its first k digits and the remaining digits are
+// compared with the stored digits.
+
+package main
+
+import (
+    "bufio"
+    "encoding/binary"
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "log/slog"
+    "os"
+    "sort"
+    "time"
+)
+
+const (
+    indexMagic   = "PIDIGIDX"
+    indexVersion = 1
+
+    indexHeaderSize = 32
+
+    defaultIndexK = 6
+    maxIndexK     = 8
+
+    // Number of positions sorted in memory at a time while building
+    indexSweepPositions = 1 << 26
+)
+
+type indexHeader struct {
+    Magic    [8]byte
+    Version  uint32
+    K        uint32
+    Width    uint32
+    Reserved uint32
+    Digits   uint64
+}
+
+var indexCommand = &command{
+    name:  "index",
+    args:  "",
+    short: "build a search index over a digit file for the query command",
+    setup: setupIndex,
+}
+
+var queryCommand = &command{
+    name:  "query",
+    args:  "pattern",
+    short: "print every position of a digit sequence using an index",
+    setup: setupQuery,
+}
+
+func setupIndex(fs *flag.FlagSet) func(args []string) error {
+    file := fs.String("file", "", "digit file to index")
+    out := fs.String("out", "",
+        "index file to write, by default the digit file name with .idx\n"+
+            "appended")
+    k := fs.Int("k", defaultIndexK, "length of the indexed digit sequences")
+
+    return func(args []string) error {
+        if len(args) > 0 {
+            return usagef("unexpected arguments")
+        }
+        if *file == "" {
+            return usagef("missing -file")
+        }
+        if *k < 1 || *k > maxIndexK {
+            return usagef("-k must be between 1 and %d", maxIndexK)
+        }
+        if *out == "" {
+            *out = *file + ".idx"
+        }
+
+        start := time.Now()
+        n, err := buildIndex(*file, *out, *k)
+        if err != nil {
+            return err
+        }
+        elapsed := time.Since(start)
+        slog.Info(fmt.Sprintf("indexed %d digits in %s", n,
+            elapsed.Round(time.Millisecond)), "digits", n,
+            "seconds", elapsed.Seconds())
+        return nil
+    }
+}
+
+func setupQuery(fs *flag.FlagSet) func(args []string) error {
+    index := fs.String("index", "", "index file written by the index command")
+
+    return func(args []string) error {
+        if len(args) != 1 {
+            return usagef("expected exactly one pattern")
+        }
+        if err := checkPattern(args[0]); err != nil {
+            return err
+        }
+        if *index == "" {
+            return usagef("missing -index")
+        }
+
+        start := time.Now()
+        idx, err := openIndex(*index)
+        if err != nil {
+            return err
+        }
+        defer idx.close()
+
+        positions, err := idx.query(args[0])
+        if err != nil {
+            return err
+        }
+
+        w := bufio.NewWriter(os.Stdout)
+        for _, p := range positions {
+            fmt.Fprintln(w, p)
+        }
+        if err := w.Flush(); err != nil {
+            return err
+        }
+        elapsed := time.Since(start)
+        slog.Info(fmt.Sprintf("%s within %d digits in %s",
+            occurrences(int64(len(positions))), idx.header.Digits,
+            elapsed.Round(time.Microsecond)), "occurrences", len(positions),
+            "digits", idx.header.Digits, "seconds", elapsed.Seconds())
+        return nil
+    }
+}
+
+func pow10(k int) uint64 {
+    p := uint64(1)
+    for ; k > 0; k-- {
+        p *= 10
+    }
+    return p
+}
+
+// Write the index of the digit file name to the file out, return the
+// number of digits
+func buildIndex(name, out string, k int) (uint64, error) {
+    digits, in, err := openDigits(name, 0)
+    if err != nil {
+        return 0, err
+    }
+    defer in.Close()
+
+    f, err := os.Create(out)
+    if err != nil {
+        return 0, err
+    }
+    defer f.Close()
+
+    // Pass 1: store the digits and count the k-grams
+    buckets := pow10(k)
+    counts := make([]uint64, buckets+1)
+    w := bufio.NewWriterSize(f, 1<<20)
+    if _, err := w.Write(make([]byte, indexHeaderSize)); err != nil {
+        return 0, err
+    }
+
+    var n, gram uint64
+    var pending byte
+    for {
+        c, err := digits.next()
+        if errors.Is(err, io.EOF) {
+            break
+        }
+        if err != nil {
+            return 0, err
+        }
+        d := uint64(c - '0')
+
+        if n%2 == 0 {
+            pending = byte(d) << 4
+        } else if err := w.WriteByte(pending | byte(d)); err != nil {
+            return 0, err
+        }
+        n++
+
+        gram = (gram*10 + d) % buckets
+        if n >= uint64(k) {
+            counts[gram]++
+        }
+    }
+    if n%2 == 1 {
+        if err := w.WriteByte(pending); err != nil {
+            return 0, err
+        }
+    }
+
+    // The table: running totals of the counts
+    total := uint64(0)
+    for i := range counts {
+        c := counts[i]
+        counts[i] = total
+        total += c
+    }
+    if err := binary.Write(w, binary.LittleEndian, counts); err != nil {
+        return 0, err
+    }
+    if err := w.Flush(); err != nil {
+        return 0, err
+    }
+
+    header := indexHeader{Version: indexVersion, K: uint32(k), Width: 8,
+        Digits: n}
+    copy(header.Magic[:], indexMagic)
+    if n < 1<<32 {
+        header.Width = 4
+    }
+    if err := writeIndexHeader(f, &header); err != nil {
+        return 0, err
+    }
+
+    // Pass 2: sort the positions bucket by bucket, as many buckets at a
+    // time as fit in memory, reading the stored digits again
+    idx := &index{f: f, header: header}
+    positionsStart := idx.tableOffset() + int64(8*(buckets+1))
+    if _, err := f.Seek(positionsStart, io.SeekStart); err != nil {
+        return 0, err
+    }
+    w.Reset(f)
+    for lo := uint64(0); lo < buckets; {
+        hi := lo + 1
+        for hi < buckets &&
+            counts[hi+1]-counts[lo] <= indexSweepPositions {
+            hi++
+        }
+        if err := idx.sweep(w, counts, lo, hi); err != nil {
+            return 0, err
+        }
+        lo = hi
+    }
+    if err := w.Flush(); err != nil {
+        return 0, err
+    }
+
+    return n, f.Close()
+}
+
+func writeIndexHeader(f *os.File, header *indexHeader) error {
+    if _, err := f.Seek(0, io.SeekStart); err != nil {
+        return err
+    }
+    return binary.Write(f, binary.LittleEndian, header)
+}
+
+// Write the positions of the k-grams in the buckets [lo, hi)
+func (idx *index) sweep(w io.Writer, table []uint64, lo, hi uint64) error {
+    base := table[lo]
+    positions := make([]uint64, table[hi]-base)
+    next := make([]uint64, hi-lo)
+    for b := lo; b < hi; b++ {
+        next[b-lo] = table[b] - base
+    }
+
+    k := uint64(idx.header.K)
+    buckets := pow10(int(k))
+    r := bufio.NewReaderSize(io.NewSectionReader(idx.f, indexHeaderSize,
+        int64(idx.header.Digits+1)/2), 1<<20)
+
+    var gram uint64
+    var b byte
+    for n := uint64(0); n < idx.header.Digits; n++ {
+        var d uint64
+        if n%2 == 0 {
+            var err error
+            if b, err = r.ReadByte(); err != nil {
+                return err
+            }
+            d = uint64(b >> 4)
+        } else {
+            d = uint64(b & 0x0f)
+        }
+
+        gram = (gram*10 + d) % buckets
+        if n+1 >= k && gram >= lo && gram < hi {
+            positions[next[gram-lo]] = n + 2 - k
+            next[gram-lo]++
+        }
+    }
+
+    return idx.writePositions(w, positions)
+}
+
+func (idx *index) writePositions(w io.Writer, positions []uint64) error {
+    buf := make([]byte, 8)
+    for _, p := range positions {
+        var err error
+        if idx.header.Width == 4 {
+            binary.LittleEndian.PutUint32(buf, uint32(p))
+            _, err = w.Write(buf[:4])
+        } else {
+            binary.LittleEndian.PutUint64(buf, p)
+            _, err = w.Write(buf)
+        }
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// An open index file
+type index struct {
+    f      *os.File
+    header indexHeader
+}
+
+func openIndex(name string) (*index, error) {
+    f, err := os.Open(name)
+    if err != nil {
+        return nil, err
+    }
+    idx := &index{f: f}
+    if err := binary.Read(f, binary.LittleEndian, &idx.header); err != nil {
+        f.Close()
+        return nil, fmt.Errorf("%s: reading index header: %v", name, err)
+    }
+    h := &idx.header
+    if string(h.Magic[:]) != indexMagic || h.Version != indexVersion ||
+        h.K < 1 || h.K > maxIndexK || (h.Width != 4 && h.Width != 8) {
+        f.Close()
+        return nil, fmt.Errorf("%s: not an index file of this version", name)
+    }
+    return idx, nil
+}
+
+func (idx *index) close() error {
+    return idx.f.Close()
+}
+
+func (idx *index) tableOffset() int64 {
+    return indexHeaderSize + int64(idx.header.Digits+1)/2
+}
+
+// Return the table entry i
+func (idx *index) tableEntry(i uint64) (uint64, error) {
+    var buf [8]byte
+    _, err := idx.f.ReadAt(buf[:], idx.tableOffset()+int64(8*i))
+    if err != nil {
+        return 0, err
+    }
+    return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+// Return the positions with the indexes [from, to)
+func (idx *index) positions(from, to uint64) ([]uint64, error) {
+    width := uint64(idx.header.Width)
+    start := idx.tableOffset() + int64(8*(pow10(int(idx.header.K))+1))
+    buf := make([]byte, (to-from)*width)
+    if _, err := idx.f.ReadAt(buf, start+int64(from*width)); err != nil {
+        return nil, err
+    }
+
+    positions := make([]uint64, to-from)
+    for i := range positions {
+        if width == 4 {
+            positions[i] = uint64(binary.LittleEndian.Uint32(buf[4*i:]))
+        } else {
+            positions[i] = binary.LittleEndian.Uint64(buf[8*i:])
+        }
+    }
+    return positions, nil
+}
+
+// Return the n digits starting at the 1-based position as ASCII
+func (idx *index) digits(position uint64, n int) ([]byte, error) {
+    first := (position - 1) / 2
+    last := (position - 1 + uint64(n) - 1) / 2
+    buf := make([]byte, last-first+1)
+    _, err := idx.f.ReadAt(buf, indexHeaderSize+int64(first))
+    if err != nil {
+        return nil, err
+    }
+
+    digits := make([]byte, n)
+    for i := range digits {
+        j := position - 1 + uint64(i)
+        b := buf[j/2-first]
+        if j%2 == 0 {
+            b >>= 4
+        }
+        digits[i] = '0' + b&0x0f
+    }
+    return digits, nil
+}
+
+// Return the ascending positions of all occurrences of pattern
+func (idx *index) query(pattern string) ([]uint64, error) {
+    k := int(idx.header.K)
+    n := idx.header.Digits
+    if uint64(len(pattern)) > n {
+        return nil, nil
+    }
+
+    // The buckets of all k-grams starting with the pattern, or with its
+    // first k digits
+    prefix := pattern
+    if len(prefix) > k {
+        prefix = prefix[:k]
+    }
+    var lo uint64
+    for _, c := range prefix {
+        lo = lo*10 + uint64(c-'0')
+    }
+    lo *= pow10(k - len(prefix))
+    hi := lo + pow10(k-len(prefix))
+
+    from, err := idx.tableEntry(lo)
+    if err != nil {
+        return nil, err
+    }
+    to, err := idx.tableEntry(hi)
+    if err != nil {
+        return nil, err
+    }
+    candidates, err := idx.positions(from, to)
+    if err != nil {
+        return nil, err
+    }
+
+    var found []uint64
+    if len(pattern) > k {
+        for _, p := range candidates {
+            if p+uint64(len(pattern))-1 > n {
+                continue
+            }
+            rest, err := idx.digits(p+uint64(k), len(pattern)-k)
+            if err != nil {
+                return nil, err
+            }
+            if string(rest) == pattern[k:] {
+                found = append(found, p)
+            }
+        }
+        return found, nil
+    }
+
+    // A short pattern may also start within the last k-1 digits, which
+    // begin no k-gram
+    found = candidates
+    tailStart := uint64(1)
+    if n > uint64(k)-1 {
+        tailStart = n - uint64(k) + 2
+    }
+    tail, err := idx.digits(tailStart, int(n-tailStart+1))
+    if err != nil {
+        return nil, err
+    }
+    for i := 0; i+len(pattern) <= len(tail); i++ {
+        if string(tail[i:i+len(pattern)]) == pattern {
+            found = append(found, tailStart+uint64(i))
+        }
+    }
+    sort.Slice(found, func(i, j int) bool { return found[i] < found[j] })
+    return found, nil
+}
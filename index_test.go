@@ -0,0 +1,57 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "slices"
+    "testing"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+func TestIndexQuery(t *testing.T) {
+    const k = 3
+    dir := t.TempDir()
+    name := filepath.Join(dir, "pi.txt")
+    digits := pi.Digits(5001)
+    if err := os.WriteFile(name, []byte(digits), 0644); err != nil {
+        t.Fatal(err)
+    }
+    out := filepath.Join(dir, "pi.idx")
+    if _, err := buildIndex(name, out, k); err != nil {
+        t.Fatal(err)
+    }
+    idx, err := openIndex(out)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer idx.close()
+
+    // Shorter than, as long as and longer than k, and at the very end,
+    // where the last k-1 digits begin no k-gram
+    last := digits[len(digits)-5:]
+    for _, pattern := range []string{"7", "26", "592", "9999", "14159",
+        last[3:], last, "31415926"} {
+        d, c, err := openDigits(name, 0)
+        if err != nil {
+            t.Fatal(err)
+        }
+        var want []uint64
+        err = searchDigits(d, pattern, func(position int64) bool {
+            want = append(want, uint64(position))
+            return true
+        })
+        c.Close()
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        got, err := idx.query(pattern)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if !slices.Equal(got, want) {
+            t.Errorf("%s: index finds %v, search %v", pattern, got, want)
+        }
+    }
+}
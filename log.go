@@ -0,0 +1,104 @@
+// Logging: everything but the results goes through log/slog to stderr,
+// keeping stdout for the digits.
+//
+// Every command has the flags -verbose, for the debug messages as well,
+// -quiet, for the errors only, and -log-format. The text format writes the
+// messages plainly, warnings prefixed by "warning:", and json writes one
+// object per message with the values of the message as attributes.
+
+package main
+
+import (
+    "context"
+    "flag"
+    "io"
+    "log/slog"
+    "os"
+    "sync"
+)
+
+type logFlags struct {
+    verbose bool
+    quiet   bool
+    format  string
+}
+
+// Define the flags on fs. A command with a -quiet of its own keeps it.
+func defineLogFlags(fs *flag.FlagSet) *logFlags {
+    l := &logFlags{}
+    fs.BoolVar(&l.verbose, "verbose", false,
+        "also log the debug messages on stderr")
+    if fs.Lookup("quiet") == nil {
+        fs.BoolVar(&l.quiet, "quiet", false,
+            "log nothing but errors on stderr")
+    }
+    fs.StringVar(&l.format, "log-format", "text",
+        "format of the messages on stderr: text or json")
+    return l
+}
+
+// Make the logger of the flags the default logger
+func (l *logFlags) setup() error {
+    if l.verbose && l.quiet {
+        return usagef("-verbose and -quiet exclude each other")
+    }
+    level := slog.LevelInfo
+    switch {
+    case l.verbose:
+        level = slog.LevelDebug
+    case l.quiet:
+        level = slog.LevelError
+    }
+
+    switch l.format {
+    case "text":
+        slog.SetDefault(slog.New(newMessageHandler(os.Stderr, level)))
+    case "json":
+        slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr,
+            &slog.HandlerOptions{Level: level})))
+    default:
+        return usagef("invalid -log-format %q, choose text or json",
+            l.format)
+    }
+    return nil
+}
+
+// Report whether messages of the level are logged
+func logging(level slog.Level) bool {
+    return slog.Default().Enabled(context.Background(), level)
+}
+
+// The handler of the text format: one line per message, without the time
+// and the attributes, which the message spells out already
+type messageHandler struct {
+    mu    *sync.Mutex
+    w     io.Writer
+    level slog.Level
+}
+
+func newMessageHandler(w io.Writer, level slog.Level) *messageHandler {
+    return &messageHandler{&sync.Mutex{}, w, level}
+}
+
+func (h *messageHandler) Enabled(_ context.Context, level slog.Level) bool {
+    return level >= h.level
+}
+
+func (h *messageHandler) Handle(_ context.Context, r slog.Record) error {
+    line := r.Message + "\n"
+    if r.Level == slog.LevelWarn {
+        line = "warning: " + line
+    }
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    _, err := io.WriteString(h.w, line)
+    return err
+}
+
+func (h *messageHandler) WithAttrs([]slog.Attr) slog.Handler {
+    return h
+}
+
+func (h *messageHandler) WithGroup(string) slog.Handler {
+    return h
+}
@@ -0,0 +1,89 @@
+// Metrics of the serve command in the Prometheus text format.
+
+package main
+
+import (
+    "fmt"
+    "io"
+    "net/http"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// Upper bounds of the buckets of the computation latency histogram, in
+// seconds
+var latencyBuckets = []float64{
+    0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300,
+}
+
+// The counters of a server
+type serverMetrics struct {
+    requests  atomic.Int64 // requests of digits
+    digits    atomic.Int64 // digits after the point sent
+    cacheHits atomic.Int64 // requests answered from the cache
+    inFlight  atomic.Int64 // computations running
+    coalesced atomic.Int64 // requests waiting for the computation of another
+
+    mu      sync.Mutex
+    buckets []int64 // per bucket of latencyBuckets, cumulated on output
+    count   int64
+    sum     float64
+}
+
+func newServerMetrics() *serverMetrics {
+    return &serverMetrics{buckets: make([]int64, len(latencyBuckets))}
+}
+
+// Add the latency of a completed computation to the histogram
+func (m *serverMetrics) observe(latency time.Duration) {
+    seconds := latency.Seconds()
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for i, bound := range latencyBuckets {
+        if seconds <= bound {
+            m.buckets[i]++
+            break
+        }
+    }
+    m.count++
+    m.sum += seconds
+}
+
+// GET /metrics
+func (m *serverMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+    m.write(w)
+}
+
+func (m *serverMetrics) write(w io.Writer) {
+    metric := func(name, kind, help string, value int64) {
+        fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help,
+            name, kind, name, value)
+    }
+    metric("pi_requests_total", "counter", "Requests of digits.",
+        m.requests.Load())
+    metric("pi_digits_served_total", "counter",
+        "Digits after the point sent to clients.", m.digits.Load())
+    metric("pi_cache_hits_total", "counter",
+        "Requests answered from the cache.", m.cacheHits.Load())
+    metric("pi_coalesced_requests_total", "counter",
+        "Requests answered by the computation of another request.",
+        m.coalesced.Load())
+    metric("pi_computations_in_flight", "gauge",
+        "Computations running.", m.inFlight.Load())
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    const name = "pi_computation_seconds"
+    fmt.Fprintf(w, "# HELP %s Latency of the computations.\n", name)
+    fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+    var cumulated int64
+    for i, bound := range latencyBuckets {
+        cumulated += m.buckets[i]
+        fmt.Fprintf(w, "%s_bucket{le=\"%g\"} %d\n", name, bound, cumulated)
+    }
+    fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, m.count)
+    fmt.Fprintf(w, "%s_sum %g\n", name, m.sum)
+    fmt.Fprintf(w, "%s_count %d\n", name, m.count)
+}
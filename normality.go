@@ -0,0 +1,264 @@
+// Statistical tests for the normality of the digits: if pi is normal, the
+// digits behave like a sequence of independent, uniformly distributed random
+// digits and the p-values of the tests are uniformly distributed, too.
+// Very small p-values would be suspicious.
+
+package main
+
+import (
+    "fmt"
+    "io"
+    "math"
+    "text/tabwriter"
+)
+
+// Number of digits of a poker hand
+const pokerHandSize = 5
+
+// Poker hand categories: all different, one pair, two pairs, three of a
+// kind, and full house, four or five of a kind lumped together so that
+// every category can be expected often enough
+const pokerCategories = 5
+
+// Probabilities of the poker hand categories for uniform random digits
+var pokerProbabilities = [pokerCategories]float64{
+    0.3024, 0.504, 0.108, 0.072, 0.0136,
+}
+
+// Counters for the tests, fed by digitStats.add
+type normalityCounters struct {
+    hand  [pokerHandSize]int
+    hands [pokerCategories]int64
+
+    // Runs of low (0-4) and high (5-9) digits
+    low, high int64
+    runs      int64
+    lastHigh  bool
+}
+
+func (c *normalityCounters) add(d int, position int64) {
+    i := int((position - 1) % pokerHandSize)
+    c.hand[i] = d
+    if i == pokerHandSize-1 {
+        c.hands[pokerCategory(c.hand)]++
+    }
+
+    high := d >= 5
+    if high {
+        c.high++
+    } else {
+        c.low++
+    }
+    if position == 1 || high != c.lastHigh {
+        c.runs++
+    }
+    c.lastHigh = high
+}
+
+// Return the category of a poker hand, see pokerProbabilities
+func pokerCategory(hand [pokerHandSize]int) int {
+    var counts [10]int
+    for _, d := range hand {
+        counts[d]++
+    }
+    pairs, triples, more := 0, 0, 0
+    for _, n := range counts {
+        switch {
+        case n == 2:
+            pairs++
+        case n == 3:
+            triples++
+        case n > 3:
+            more++
+        }
+    }
+
+    switch {
+    case more > 0 || (triples == 1 && pairs == 1):
+        return 4
+    case triples == 1:
+        return 3
+    case pairs == 2:
+        return 2
+    case pairs == 1:
+        return 1
+    }
+    return 0
+}
+
+// The result of a test
+type testResult struct {
+    name      string
+    statistic string // e.g. "chi2 = 8.93"
+    dof       int    // degrees of freedom, 0 for a normal distribution
+    p         float64
+    skipped   string // reason why the test was not done
+}
+
+// Run the tests on the statistics
+func (s *digitStats) normalityTests() []testResult {
+    return []testResult{
+        s.frequencyTest(),
+        s.serialTest(),
+        s.pokerTest(),
+        s.runsTest(),
+    }
+}
+
+// Chi-square test of the digit frequencies
+func (s *digitStats) frequencyTest() testResult {
+    r := testResult{name: "frequency", dof: 9}
+    if s.count < 50 {
+        r.skipped = "needs at least 50 digits"
+        return r
+    }
+
+    expected := float64(s.count) / 10
+    chi2 := 0.0
+    for _, n := range s.freq {
+        diff := float64(n) - expected
+        chi2 += diff * diff / expected
+    }
+    r.statistic = fmt.Sprintf("chi2 = %.3f", chi2)
+    r.p = chiSquareP(chi2, r.dof)
+    return r
+}
+
+// Good's serial test of the overlapping digit pairs
+func (s *digitStats) serialTest() testResult {
+    r := testResult{name: "serial", dof: 90}
+    n := float64(s.count - 1)
+    if n < 500 {
+        r.skipped = "needs at least 500 digits"
+        return r
+    }
+
+    // psi2 for pairs minus psi2 for single digits
+    pairs, singles := 0.0, 0.0
+    for a := 0; a < 10; a++ {
+        singles += float64(s.freq[a]) * float64(s.freq[a])
+        for b := 0; b < 10; b++ {
+            pairs += float64(s.pairs[a][b]) * float64(s.pairs[a][b])
+        }
+    }
+    psi := (100/n*pairs - n) - (10/float64(s.count)*singles - float64(s.count))
+    r.statistic = fmt.Sprintf("psi2 = %.3f", psi)
+    r.p = chiSquareP(psi, r.dof)
+    return r
+}
+
+// Chi-square test of the poker hand categories of consecutive groups of
+// five digits
+func (s *digitStats) pokerTest() testResult {
+    r := testResult{name: "poker", dof: pokerCategories - 1}
+    hands := 0.0
+    for _, n := range s.normality.hands {
+        hands += float64(n)
+    }
+    // The rarest category needs about five expected hands
+    if hands < 400 {
+        r.skipped = "needs at least 2000 digits"
+        return r
+    }
+
+    chi2 := 0.0
+    for i, n := range s.normality.hands {
+        expected := hands * pokerProbabilities[i]
+        diff := float64(n) - expected
+        chi2 += diff * diff / expected
+    }
+    r.statistic = fmt.Sprintf("chi2 = %.3f", chi2)
+    r.p = chiSquareP(chi2, r.dof)
+    return r
+}
+
+// Wald-Wolfowitz test of the runs of low (0-4) and high (5-9) digits
+func (s *digitStats) runsTest() testResult {
+    r := testResult{name: "runs"}
+    c := &s.normality
+    if c.low < 10 || c.high < 10 {
+        r.skipped = "needs at least 10 low and 10 high digits"
+        return r
+    }
+
+    n1, n2 := float64(c.low), float64(c.high)
+    n := n1 + n2
+    mean := 2*n1*n2/n + 1
+    variance := 2 * n1 * n2 * (2*n1*n2 - n) / (n * n * (n - 1))
+    z := (float64(c.runs) - mean) / math.Sqrt(variance)
+    r.statistic = fmt.Sprintf("z = %.3f (%d runs)", z, c.runs)
+    r.p = math.Erfc(math.Abs(z) / math.Sqrt2)
+    return r
+}
+
+func printTests(out io.Writer, results []testResult) error {
+    w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+    fmt.Fprintf(w, "test\tstatistic\tdof\tp-value\n")
+    for _, r := range results {
+        if r.skipped != "" {
+            fmt.Fprintf(w, "%s\tskipped, %s\t\t\n", r.name, r.skipped)
+            continue
+        }
+        dof := "normal"
+        if r.dof > 0 {
+            dof = fmt.Sprint(r.dof)
+        }
+        fmt.Fprintf(w, "%s\t%s\t%s\t%.4f\n", r.name, r.statistic, dof, r.p)
+    }
+    return w.Flush()
+}
+
+// Return the probability that a chi-square distributed variable with the
+// given degrees of freedom is at least x
+func chiSquareP(x float64, dof int) float64 {
+    if x <= 0 {
+        return 1
+    }
+    return upperGamma(float64(dof)/2, x/2)
+}
+
+// Return the regularized upper incomplete gamma function Q(a, x), with the
+// series for x < a+1 and the continued fraction otherwise as in Numerical
+// Recipes
+func upperGamma(a, x float64) float64 {
+    const epsilon = 1e-15
+    lg, _ := math.Lgamma(a)
+
+    if x < a+1 {
+        sum, term := 1/a, 1/a
+        for n := 1.0; n < 1000; n++ {
+            term *= x / (a + n)
+            sum += term
+            if math.Abs(term) < math.Abs(sum)*epsilon {
+                break
+            }
+        }
+        return 1 - sum*math.Exp(-x+a*math.Log(x)-lg)
+    }
+
+    // Modified Lentz's method
+    const tiny = 1e-300
+    b := x + 1 - a
+    c := 1 / tiny
+    d := 1 / b
+    h := d
+    for i := 1.0; i < 1000; i++ {
+        an := -i * (i - a)
+        b += 2
+        d = an*d + b
+        if math.Abs(d) < tiny {
+            d = tiny
+        }
+        c = b + an/c
+        if math.Abs(c) < tiny {
+            c = tiny
+        }
+        d = 1 / d
+        delta := d * c
+        h *= delta
+        if math.Abs(delta-1) < epsilon {
+            break
+        }
+    }
+    return math.Exp(-x+a*math.Log(x)-lg) * h
+}
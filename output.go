@@ -0,0 +1,160 @@
+// Selecting and laying out the digits compute prints.
+
+package main
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "strings"
+    "time"
+)
+
+// Return the digits after the point selected by -offset and -length, the
+// last ones of -tail labeled with their places, or all of them; without
+// the point for -raw
+func (f *computeFlags) window(digits string) string {
+    point := strings.IndexByte(digits, '.')
+    switch {
+    case f.offset > 0:
+        // The first place after the point has offset 1
+        start := point + f.offset
+        return digits[start : start+f.length]
+    case f.tail > 0 && point >= 0:
+        places := len(digits) - point - 1
+        n := min(f.tail, places)
+        return fmt.Sprintf("%d-%d: %s", places-n+1, places,
+            digits[len(digits)-n:])
+    case f.raw && !f.leading:
+        if point < 0 {
+            return ""
+        }
+        return digits[point+1:]
+    case f.raw && point >= 0:
+        return digits[:point] + digits[point+1:]
+    }
+    return digits
+}
+
+// Write the digits as selected and laid out by the flags, ending with a
+// newline unless -raw
+func (f *computeFlags) writeDigits(w io.Writer, digits string) error {
+    if f.raw {
+        _, err := io.WriteString(w, digits)
+        return err
+    }
+    if f.group == 0 && f.line == 0 {
+        _, err := fmt.Fprintln(w, digits)
+        return err
+    }
+
+    lw := &layoutWriter{
+        w:       w,
+        group:   f.group,
+        line:    f.line,
+        numbers: f.numberLines,
+        intPart: f.offset == 0,
+        place:   max(f.offset, 1),
+    }
+    last := lw.place + len(digits)
+    lw.width = len(fmt.Sprint(last))
+    for len(digits) > 0 {
+        n := min(len(digits), layoutChunk)
+        if _, err := lw.Write([]byte(digits[:n])); err != nil {
+            return err
+        }
+        digits = digits[n:]
+    }
+    return lw.end()
+}
+
+// The digits go through the layoutWriter in pieces of this size
+const layoutChunk = 1 << 16
+
+// Streams digits in groups and lines, the place after the point of the
+// first digit of every line in front if numbers is set
+type layoutWriter struct {
+    w       io.Writer
+    group   int  // digits per group, 0 for no groups
+    line    int  // digits per line, 0 for a single line
+    numbers bool // label the lines with their places
+    width   int  // of the labels
+
+    intPart bool // still in the integer part and the point
+    place   int  // of the next digit, the first after the point is 1
+    column  int  // digits in the current line
+    buf     []byte
+}
+
+func (l *layoutWriter) Write(p []byte) (int, error) {
+    buf := l.buf[:0]
+    for _, c := range p {
+        if l.intPart {
+            buf = append(buf, c)
+            if c == '.' {
+                l.intPart = false
+                if l.line > 0 {
+                    buf = append(buf, '\n')
+                }
+            }
+            continue
+        }
+
+        switch {
+        case l.column == 0 && l.numbers:
+            buf = fmt.Appendf(buf, "%*d  ", l.width, l.place)
+        case l.column > 0 && l.group > 0 && l.column%l.group == 0:
+            buf = append(buf, ' ')
+        }
+        buf = append(buf, c)
+        l.place++
+        l.column++
+        if l.column == l.line {
+            buf = append(buf, '\n')
+            l.column = 0
+        }
+    }
+    l.buf = buf
+    if _, err := l.w.Write(buf); err != nil {
+        return 0, err
+    }
+    return len(p), nil
+}
+
+// End the last line
+func (l *layoutWriter) end() error {
+    if l.column == 0 && !l.intPart && l.line > 0 {
+        return nil
+    }
+    _, err := l.w.Write([]byte{'\n'})
+    return err
+}
+
+// What -format json writes, the SHA-256 digest covers the value
+type jsonResult struct {
+    Digits    int    `json:"digits"`
+    Constant  string `json:"constant"`
+    Algorithm string `json:"algorithm,omitempty"`
+    Value     string `json:"value"`
+    SHA256    string `json:"sha256"`
+    Elapsed   int64  `json:"elapsed_ms"`
+}
+
+func newJSONResult(places int, digits string, report *runReport,
+    elapsed time.Duration) *jsonResult {
+    sum := sha256.Sum256([]byte(digits))
+    return &jsonResult{
+        Digits:    places,
+        Constant:  report.Constant,
+        Algorithm: report.Algorithm,
+        Value:     digits,
+        SHA256:    hex.EncodeToString(sum[:]),
+        Elapsed:   elapsed.Milliseconds(),
+    }
+}
+
+func (r *jsonResult) write(w io.Writer) error {
+    return json.NewEncoder(w).Encode(r)
+}
@@ -0,0 +1,35 @@
+// Parallelism: every command has the flag -j, or -parallelism, bounding
+// the CPUs used at once, for shared machines and containers with a CPU
+// quota.
+//
+// The series and the conversion to digits of package pi split their work
+// by GOMAXPROCS, and so do the computations of serve, so -j sets it. By
+// default GOMAXPROCS stays that of the Go runtime, the number of CPUs or
+// the quota of the container.
+
+package main
+
+import (
+    "flag"
+    "runtime"
+)
+
+// Define -j and -parallelism on fs, both setting the returned value
+func defineParallelism(fs *flag.FlagSet) *int {
+    j := new(int)
+    fs.IntVar(j, "j", 0,
+        "use at most this many CPUs at once, by default GOMAXPROCS")
+    fs.IntVar(j, "parallelism", 0, "same as -j")
+    return j
+}
+
+// Bound the CPUs used at once to j, 0 for the default
+func setParallelism(j int) error {
+    if j < 0 {
+        return usagef("invalid -j %d, need at least 1", j)
+    }
+    if j > 0 {
+        runtime.GOMAXPROCS(j)
+    }
+    return nil
+}
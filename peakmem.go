@@ -0,0 +1,17 @@
+// Peak memory use of the process, the platform specific files provide
+// peakMemory.
+
+package main
+
+import (
+    "runtime"
+)
+
+// Return the memory obtained from the OS by the Go runtime. It hardly ever
+// shrinks, which makes it an estimate for the peak use where the OS does not
+// tell.
+func fallbackPeakMemory() uint64 {
+    var stats runtime.MemStats
+    runtime.ReadMemStats(&stats)
+    return stats.Sys
+}
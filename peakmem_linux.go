@@ -0,0 +1,15 @@
+package main
+
+import (
+    "syscall"
+)
+
+// Return the peak resident set size of the process in bytes
+func peakMemory() uint64 {
+    var usage syscall.Rusage
+    if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+        return fallbackPeakMemory()
+    }
+    // Linux reports kilobytes
+    return uint64(usage.Maxrss) * 1024
+}
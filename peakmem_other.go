@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package main
+
+// Return an estimate of the peak memory use of the process in bytes
+func peakMemory() uint64 {
+    return fallbackPeakMemory()
+}
@@ -0,0 +1,91 @@
+// The algorithms available for computing pi.
+
+package pi
+
+import (
+    "sort"
+)
+
+// Name of the algorithm used when none is given, which picks one with
+// AutoAlgorithm
+const DefaultAlgorithm = "auto"
+
+type algorithm struct {
+    name    string
+    formula string
+
+    compute  fixedFunc
+    maxError errorFunc
+    bounds   intervalFunc // nil if the formula has no bounds
+}
+
+var algorithms = map[string]*algorithm{
+    "borwein": {
+        name:     "borwein",
+        formula:  "1/pi = lim a(k), a(k+1) = a(k) (1+y(k+1))^4 - " +
+            "2^(2k+3) y(k+1) (1+y(k+1)+y(k+1)^2), " +
+            "y(k+1) = (1-(1-y(k)^4)^(1/4)) / (1+(1-y(k)^4)^(1/4))",
+        compute:  borwein,
+        maxError: borweinError,
+    },
+    "chudnovsky": {
+        name:     "chudnovsky",
+        formula:  "1/pi = 12 * sum((-1)^k (6k)! (13591409 + 545140134k) / " +
+            "((3k)! (k!)^3 640320^(3k+3/2)))",
+        compute:  chudnovsky,
+        maxError: chudnovskyError,
+        bounds:   chudnovskyInterval,
+    },
+    "machin": {
+        name:     "machin",
+        formula:  "pi = 16*arccot(5) - 4*arccot(239)",
+        compute:  machin,
+        maxError: machinError,
+        bounds:   machinInterval,
+    },
+}
+
+// Return the names of the available algorithms in alphabetical order, "auto"
+// not among them
+func Algorithms() []string {
+    names := make([]string, 0, len(algorithms))
+    for name := range algorithms {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// Return the algorithm "auto" picks for the options. Of the algorithms
+// here, chudnovsky is the fastest at any number of digits, so that there
+// is no crossover to choose by.
+func AutoAlgorithm(opts *Options) string {
+    return "chudnovsky"
+}
+
+// Return the name of the algorithm of the options, the choice of
+// AutoAlgorithm for "" and "auto"
+func (opts *Options) algorithm() string {
+    if opts.Algorithm == "" || opts.Algorithm == "auto" {
+        return AutoAlgorithm(opts)
+    }
+    return opts.Algorithm
+}
+
+func lookupAlgorithm(name string) (*algorithm, error) {
+    alg, ok := algorithms[name]
+    if !ok {
+        return nil, errorf(ErrUnknownAlgorithm, "pi: unknown algorithm %q",
+            name)
+    }
+    return alg, nil
+}
+
+// Return the formula of the named algorithm, or "" for an unknown name
+func Formula(name string) string {
+    alg, err := lookupAlgorithm((&Options{Algorithm: name}).algorithm())
+    if err != nil {
+        return ""
+    }
+    return alg.formula
+}
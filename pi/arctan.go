@@ -0,0 +1,121 @@
+// The arctangent series for Machin-like formulas of your own.
+
+package pi
+
+import (
+    "context"
+    "math/big"
+)
+
+// Return arccot(x) = arctan(1/x) in fixed point arithmetic with the given
+// unity, i.e. arccot(x) * unity, e.g. 4*ArccotFixed(5, u) -
+// ArccotFixed(239, u) is pi/4 * u. The series converges with 2*log10(|x|)
+// digits per term; x = 0 and x = ±1 give ±pi/2 and ±pi/4 without it. The
+// result is truncated at every term and may be off by up to two units per
+// term, about 2*places/log10(x**2) units in all; compute with guard digits
+// and drop them from the result.
+func ArccotFixed(x, unity *big.Int) *big.Int {
+    switch x.CmpAbs(big.NewInt(1)) {
+    case -1:
+        // arccot(0) = pi/2
+        pi, _ := machin(context.Background(), unity, nil)
+        return pi.Rsh(pi, 1)
+    case 0:
+        // arccot(±1) = ±pi/4
+        pi, _ := machin(context.Background(), unity, nil)
+        pi.Rsh(pi, 2)
+        if x.Sign() < 0 {
+            pi.Neg(pi)
+        }
+        return pi
+    }
+
+    // arccot(-x) = -arccot(x)
+    sum, _ := arccot(context.Background(), new(big.Int).Abs(x), unity, nil)
+    if x.Sign() < 0 {
+        sum.Neg(sum)
+    }
+    return sum
+}
+
+// Return arctan(p/q) in fixed point arithmetic with the given unity, i.e.
+// arctan(p/q) * unity, for q != 0. Arguments beyond ±1 are reduced with
+// arctan(y) = ±pi/2 - arctan(1/y) and those beyond ±1/2 with
+// arctan(y) = pi/4 + arctan((y-1)/(y+1)), so the series converges with at
+// least 0.6 digits per term; y = 1/x falls back to ArccotFixed. Like that,
+// the result may be off by a few units per term.
+func ArctanFixed(p, q, unity *big.Int) *big.Int {
+    if q.Sign() == 0 {
+        panic("pi: arctan with zero denominator")
+    }
+    if p.Sign() == 0 {
+        return big.NewInt(0)
+    }
+
+    // arctan(-y) = -arctan(y), continue with p, q > 0
+    negative := p.Sign() != q.Sign()
+    p = new(big.Int).Abs(p)
+    q = new(big.Int).Abs(q)
+    result := arctanPositive(p, q, unity)
+    if negative {
+        result.Neg(result)
+    }
+    return result
+}
+
+// Return arctan(p/q) * unity for p, q > 0
+func arctanPositive(p, q, unity *big.Int) *big.Int {
+    // ArccotFixed handles 1/x and the quarter pi of p = q
+    if p.Cmp(big.NewInt(1)) == 0 || p.Cmp(q) == 0 {
+        x := new(big.Int).Quo(q, p)
+        if new(big.Int).Mul(x, p).Cmp(q) == 0 {
+            return ArccotFixed(x, unity)
+        }
+    }
+
+    switch {
+    case p.Cmp(q) > 0:
+        // arctan(y) = pi/2 - arctan(1/y)
+        pi, _ := machin(context.Background(), unity, nil)
+        pi.Rsh(pi, 1)
+        return pi.Sub(pi, arctanPositive(q, p, unity))
+    case new(big.Int).Lsh(p, 1).Cmp(q) > 0:
+        // arctan(y) = pi/4 + arctan((p-q)/(p+q)), (p-q)/(p+q) is within
+        // -1/3 and 0
+        pi, _ := machin(context.Background(), unity, nil)
+        pi.Rsh(pi, 2)
+        return pi.Sub(pi, arctanSeries(new(big.Int).Sub(q, p),
+            new(big.Int).Add(p, q), unity))
+    }
+    return arctanSeries(p, q, unity)
+}
+
+// Sum the series of arctan(p/q) * unity for 0 < p/q <= 1/2
+//
+//                 p     p**3      p**5
+//    arctan(y) = -- - ------- + ------- - ...
+//                 q   3 q**3    5 q**5
+func arctanSeries(p, q, unity *big.Int) *big.Int {
+    pp := new(big.Int).Mul(p, p)
+    qq := new(big.Int).Mul(q, q)
+
+    // power = unity * (p/q)**n
+    power := new(big.Int).Mul(unity, p)
+    power.Quo(power, q)
+    sum := new(big.Int).Set(power)
+    term := new(big.Int)
+
+    for n := int64(3); ; n += 2 {
+        power.Mul(power, pp)
+        power.Quo(power, qq)
+        term.Quo(power, big.NewInt(n))
+        if term.Sign() == 0 {
+            return sum
+        }
+        if n%4 == 3 {
+            sum.Sub(sum, term)
+        } else {
+            sum.Add(sum, term)
+        }
+    }
+}
@@ -0,0 +1,226 @@
+// Hexadecimal digit extraction with the Bailey-Borwein-Plouffe formula.
+//
+//          inf    1     /   4        2        1        1    \
+//    pi =  sum  ----- * | ------ - ------ - ------ - ------ |
+//          k=0  16**k   \ 8k + 1   8k + 4   8k + 5   8k + 6 /
+//
+// The hex digits following position d are the fractional part of
+// 16**d * pi. For the terms k <= d only 16**(d-k) mod (8k+j) matters, so the
+// digits can be computed without computing the digits before them.
+//
+// The fractional parts are summed in 192 bit fixed point arithmetic, the
+// rounding errors of the up to d terms stay far below the 32 hex digits
+// that are delivered.
+
+package pi
+
+import (
+    "fmt"
+    "math"
+    "math/big"
+    "math/bits"
+    "strings"
+)
+
+// Maximum number of hex digits delivered by HexDigits
+const MaxHexDigits = 32
+
+// Return count hexadecimal digits of pi starting at the given position,
+// position 1 being the first digit after the point: HexDigits(1, 4) returns
+// "243f". Only the position and count are needed, not the digits before.
+func HexDigits(position int64, count int) (string, error) {
+    if err := checkHexDigits(position, count); err != nil {
+        return "", err
+    }
+
+    // Fractional part of 16**d * pi, the digits following position d
+    d := position - 1
+    var f fraction192
+    f = f.add(bbpSum(1, d).shl(2))
+    f = f.sub(bbpSum(4, d).shl(1))
+    f = f.sub(bbpSum(5, d))
+    f = f.sub(bbpSum(6, d))
+
+    return f.hex()[:count], nil
+}
+
+func checkHexDigits(position int64, count int) error {
+    if position < 1 {
+        return errorf(ErrInvalidPrecision,
+            "pi: invalid hex digit position %d", position)
+    }
+    if count < 0 || count > MaxHexDigits {
+        return errorf(ErrInvalidPrecision,
+            "pi: at most %d hex digits at a time", MaxHexDigits)
+    }
+    if position > math.MaxInt64/8-8 {
+        return errorf(ErrInvalidPrecision,
+            "pi: hex digit position %d too large", position)
+    }
+    return nil
+}
+
+// A number in [0, 1) in fixed point with 192 bits, most significant word
+// first. Arithmetic wraps around, i.e. it is modulo 1.
+type fraction192 [3]uint64
+
+func (a fraction192) add(b fraction192) fraction192 {
+    var c uint64
+    a[2], c = bits.Add64(a[2], b[2], 0)
+    a[1], c = bits.Add64(a[1], b[1], c)
+    a[0], _ = bits.Add64(a[0], b[0], c)
+    return a
+}
+
+func (a fraction192) sub(b fraction192) fraction192 {
+    var c uint64
+    a[2], c = bits.Sub64(a[2], b[2], 0)
+    a[1], c = bits.Sub64(a[1], b[1], c)
+    a[0], _ = bits.Sub64(a[0], b[0], c)
+    return a
+}
+
+// Multiply by 2**n modulo 1, n < 64
+func (a fraction192) shl(n uint) fraction192 {
+    if n == 0 {
+        return a
+    }
+    return fraction192{
+        a[0]<<n | a[1]>>(64-n),
+        a[1]<<n | a[2]>>(64-n),
+        a[2] << n,
+    }
+}
+
+// Divide by 2**n, n < 192
+func (a fraction192) shr(n uint) fraction192 {
+    for ; n >= 64; n -= 64 {
+        a = fraction192{0, a[0], a[1]}
+    }
+    if n == 0 {
+        return a
+    }
+    return fraction192{
+        a[0] >> n,
+        a[1]>>n | a[0]<<(64-n),
+        a[2]>>n | a[1]<<(64-n),
+    }
+}
+
+// Return the 48 hex digits of a
+func (a fraction192) hex() string {
+    return fmt.Sprintf("%016x%016x%016x", a[0], a[1], a[2])
+}
+
+// Return r / m for r < m
+func ratio192(r, m uint64) fraction192 {
+    var f fraction192
+    f[0], r = bits.Div64(r, 0, m)
+    f[1], r = bits.Div64(r, 0, m)
+    f[2], _ = bits.Div64(r, 0, m)
+    return f
+}
+
+// Return 16**e mod m
+func powMod16(e int64, m uint64) uint64 {
+    result, base := uint64(1)%m, uint64(16)%m
+    for ; e > 0; e >>= 1 {
+        if e&1 == 1 {
+            result = mulMod(result, base, m)
+        }
+        base = mulMod(base, base, m)
+    }
+    return result
+}
+
+// Return a * b mod m for a, b < m
+func mulMod(a, b, m uint64) uint64 {
+    hi, lo := bits.Mul64(a, b)
+    _, rem := bits.Div64(hi, lo, m)
+    return rem
+}
+
+// Return the fractional part of sum(16**(d-k) / (8k+j)) over all k >= 0
+func bbpSum(j, d int64) fraction192 {
+    var sum fraction192
+
+    // Terms with a non-negative power of 16: keep the fractional part only
+    for k := int64(0); k <= d; k++ {
+        m := uint64(8*k + j)
+        sum = sum.add(ratio192(powMod16(d-k, m), m))
+    }
+
+    // Terms with a negative power of 16, until they vanish in 192 bits
+    for k := d + 1; k <= d+48; k++ {
+        m := uint64(8*k + j)
+        sum = sum.add(ratio192(1, m).shr(uint(4 * (k - d))))
+    }
+
+    return sum
+}
+
+// The result of CheckTail
+type TailCheck struct {
+    Position int64  // of the first hex digit checked, 1-based
+    Computed string // hex digits converted from the computed value
+    BBP      string // the same hex digits by digit extraction
+    Formula  string // of the digit extraction, see ExtractAlgorithms
+
+    // Computed may be one unit too small in the last hex digit, as the
+    // computed value is truncated
+    upper string
+}
+
+func (c *TailCheck) Passed() bool {
+    return c.BBP == c.Computed || c.BBP == c.upper
+}
+
+// Spot-check the end of the fixed point value x = pi * 10**places, e.g.
+// as returned by Compute: the last MaxHexDigits hex digits that x determines
+// are compared with digit extraction by Bellard's formula. An error of the
+// computation usually affects the final digits, this catches silent
+// corruption without recomputing everything.
+func CheckTail(x *big.Int, places int) (*TailCheck, error) {
+    return CheckTailBase(x, places, 10)
+}
+
+// Same as CheckTail for x = pi * base**places
+func CheckTailBase(x *big.Int, places, base int) (*TailCheck, error) {
+    return CheckTailAlgo(x, places, base, ExtractAlgorithms()[0])
+}
+
+// Same as CheckTailBase with the named formula of ExtractAlgorithms
+func CheckTailAlgo(x *big.Int, places, base int,
+    algo string) (*TailCheck, error) {
+    // x determines about places * log16(base) hex digits, so that one unit
+    // of x is less than one unit of the last hex digit
+    last := int64(float64(places)*math.Log(float64(base))/math.Log(16)) - 1
+    position := last - MaxHexDigits + 1
+    if position < 1 {
+        return nil, errorf(ErrInvalidPrecision,
+            "pi: %d places are too few for a tail check", places)
+    }
+
+    extracted, err := HexDigitsAlgo(position, MaxHexDigits, algo)
+    if err != nil {
+        return nil, err
+    }
+
+    // pi * base**places is in [x, x+1)
+    scale := new(big.Int).Exp(big.NewInt(int64(base)),
+        big.NewInt(int64(places)), nil)
+    computed := tailHexDigits(x, scale, last)
+    upper := tailHexDigits(new(big.Int).Add(x, big.NewInt(1)), scale, last)
+
+    return &TailCheck{position, computed, extracted, algo, upper}, nil
+}
+
+// Return the MaxHexDigits hex digits of x / scale ending at position last
+func tailHexDigits(x, scale *big.Int, last int64) string {
+    // floor(x * 16**last / scale) mod 16**MaxHexDigits
+    h := new(big.Int).Lsh(x, uint(4*last))
+    h.Quo(h, scale)
+    h.Mod(h, new(big.Int).Lsh(big.NewInt(1), 4*MaxHexDigits))
+    s := h.Text(16)
+    return strings.Repeat("0", MaxHexDigits-len(s)) + s
+}
@@ -0,0 +1,91 @@
+// Hexadecimal digit extraction with Bellard's formula.
+//
+//            1     inf  (-1)**n    /    32       1        256       64
+//    pi =  ----- * sum  -------- * | - ---- - ------ + ------- - -------
+//           64     n=0  2**(10n)   \   4n+1   4n+3     10n+1     10n+3
+//
+//                                        4         4         1    \
+//                                    - ------- - ------- + ------- |
+//                                      10n+5     10n+7     10n+9  /
+//
+// Every term gains ten bits instead of the four of BBP, so that the digits
+// at position d take 7 sums of 0.4 d terms instead of 4 sums of d terms,
+// 30% fewer terms and about 25% less time. The fractional parts are summed
+// in the same 192 bit fixed point arithmetic as for BBP.
+
+package pi
+
+// The formulas for hex digit extraction, the first one is the default
+func ExtractAlgorithms() []string {
+    return []string{"bellard", "bbp"}
+}
+
+// The sums of Bellard's formula: (-1)**n * 2**shift / (a n + b),
+// subtracted if negative
+var bellardSums = []struct {
+    a, b     int64
+    shift    int64
+    negative bool
+}{
+    {4, 1, 5, true},
+    {4, 3, 0, true},
+    {10, 1, 8, false},
+    {10, 3, 6, true},
+    {10, 5, 2, true},
+    {10, 7, 2, true},
+    {10, 9, 0, false},
+}
+
+// Same as HexDigits with the named formula of ExtractAlgorithms
+func HexDigitsAlgo(position int64, count int,
+    algo string) (string, error) {
+    switch algo {
+    case "bbp":
+        return HexDigits(position, count)
+    case "bellard":
+    default:
+        return "", errorf(ErrUnknownAlgorithm,
+            "pi: unknown digit extraction formula %q", algo)
+    }
+    if err := checkHexDigits(position, count); err != nil {
+        return "", err
+    }
+
+    // Fractional part of 2**e * pi, the digits following position d
+    e := 4 * (position - 1)
+    var f fraction192
+    for _, s := range bellardSums {
+        sum := bellardSum(s.a, s.b, e+s.shift-6)
+        if s.negative {
+            f = f.sub(sum)
+        } else {
+            f = f.add(sum)
+        }
+    }
+    return f.hex()[:count], nil
+}
+
+// Return the fractional part of sum((-1)**n * 2**(e-10n) / (a n + b)) over
+// all n >= 0
+func bellardSum(a, b, e int64) fraction192 {
+    var sum fraction192
+    for n := int64(0); e-10*n > -192; n++ {
+        m := uint64(a*n + b)
+        var term fraction192
+        switch exp := e - 10*n; {
+        case exp >= 0:
+            term = ratio192(powMod(2, exp, m), m)
+        case m == 1:
+            // 2**exp itself, 1 is beyond the fraction
+            term = fraction192{1 << 63}.shr(uint(-exp - 1))
+        default:
+            term = ratio192(1, m).shr(uint(-exp))
+        }
+        if n%2 == 1 {
+            sum = sum.sub(term)
+        } else {
+            sum = sum.add(term)
+        }
+    }
+    return sum
+}
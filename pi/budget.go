@@ -0,0 +1,58 @@
+// Best effort computations under a deadline.
+
+package pi
+
+import (
+    "context"
+    "math"
+    "math/big"
+    "time"
+)
+
+// Number of places of the first, quick computation of ComputeLargest
+const budgetStartPlaces = 100
+
+// Compute pi with as many places as possible before ctx is done, but at
+// most maxPlaces (no limit if maxPlaces < 0). The number of places is
+// doubled until the deadline of ctx does not allow for another doubling,
+// a last computation then uses up the remaining time. Returns the largest
+// completed result and its number of places, or ctx.Err() if not even the
+// first computation could be completed.
+func ComputeLargest(ctx context.Context, maxPlaces int, opts *Options) (
+    *big.Int, int, error) {
+    if maxPlaces < 0 {
+        maxPlaces = math.MaxInt32
+    }
+
+    var best *big.Int
+    bestPlaces := 0
+    places := min(budgetStartPlaces, maxPlaces)
+
+    for places > bestPlaces || best == nil {
+        start := time.Now()
+        x, err := Compute(ctx, places, opts)
+        if err != nil {
+            if best == nil {
+                return nil, 0, err
+            }
+            break
+        }
+        best, bestPlaces = x, places
+        elapsed := time.Since(start)
+
+        next := 2 * places
+        if deadline, ok := ctx.Deadline(); ok && elapsed > 0 {
+            // The running time grows with the square of the number of
+            // places, leave some slack for the estimate being off
+            remaining := time.Until(deadline)
+            fit := float64(places) *
+                math.Sqrt(0.8*float64(remaining)/float64(elapsed))
+            if fit < float64(next) {
+                next = int(fit)
+            }
+        }
+        places = min(next, maxPlaces)
+    }
+
+    return best, bestPlaces, nil
+}
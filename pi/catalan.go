@@ -0,0 +1,95 @@
+// Catalan's constant G with Lupas' series.
+//
+//        1               k+1  8k                 2     (2k)!**3 (k!)**2
+//    G = -- sum  (-1)     2   (40k**2 - 24k + 3) ---------------------
+//        64 k=1                                  k**3 (2k-1) (4k)!**2
+//
+// The factorial part c(k) = 2**(8k) (2k)!**3 (k!)**2 / (4k)!**2 follows
+// from c(k-1) by
+//
+//    c(k) = c(k-1) * 256 (2k(2k-1))**3 k**2 / ((4k)(4k-1)(4k-2)(4k-3))**2
+//
+// and falls by about a factor of 4 per term.
+
+package pi
+
+import (
+    "context"
+    "math"
+    "math/big"
+)
+
+func init() {
+    registerConstant(&constant{
+        name:     "catalan",
+        formula:  "G = 1/64 * sum((-1)^(k+1) 2^(8k) (40k^2 - 24k + 3) " +
+            "(2k)!^3 (k!)^2 / (k^3 (2k-1) (4k)!^2))",
+        compute:  catalan,
+        maxError: catalanError,
+    })
+}
+
+// Estimate the number of terms of catalan for the given number of digits
+func catalanTerms(digits int) int {
+    return int(math.Ceil(float64(digits) / math.Log10(4)))
+}
+
+// Bound the error of catalan: c(k) is off by less than three units, its
+// factor in the terms is at most 19 for k = 1 and 5 after that, so each
+// term is off by less than 16 units and the sum, divided by 64, by a
+// quarter unit per term
+func catalanError(digits int) (*big.Float, *big.Float) {
+    e := float64(catalanTerms(digits))/4 + 4
+    return errorBounds(e, e)
+}
+
+// Compute Catalan's constant times unity with Lupas' series
+func catalan(ctx context.Context, unity *big.Int, progress *tracker) (
+    *big.Int, error) {
+    progress.expect(catalanTerms(unityDigits(unity)))
+
+    // c = c(0) * unity
+    c := new(big.Int).Set(unity)
+    sum := big.NewInt(0)
+    num := new(big.Int)
+    den := new(big.Int)
+    term := new(big.Int)
+
+    for k := int64(1); ; k++ {
+        select {
+        case <-ctx.Done():
+            return nil, ctx.Err()
+        default:
+        }
+
+        // c = c * 256 (2k(2k-1))**3 k**2 / ((4k)(4k-1)(4k-2)(4k-3))**2
+        a := big.NewInt(2 * k * (2*k - 1))
+        num.Mul(a, a)
+        num.Mul(num, a)
+        num.Mul(num, big.NewInt(256*k*k))
+        den.SetInt64(4 * k * (4*k - 1))
+        den.Mul(den, big.NewInt((4*k-2)*(4*k-3)))
+        den.Mul(den, den)
+        c.Mul(c, num)
+        c.Quo(c, den)
+
+        // term = c (40k**2 - 24k + 3) / (k**3 (2k-1))
+        term.Mul(c, big.NewInt(40*k*k-24*k+3))
+        den.SetInt64(k * k)
+        den.Mul(den, big.NewInt(k*(2*k-1)))
+        term.Quo(term, den)
+        if term.Sign() == 0 {
+            break
+        }
+        if k%2 == 1 {
+            sum.Add(sum, term)
+        } else {
+            sum.Sub(sum, term)
+        }
+
+        progress.step()
+    }
+
+    // G = sum / 64
+    return sum.Rsh(sum, 6), nil
+}
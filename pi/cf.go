@@ -0,0 +1,90 @@
+// Simple continued fractions of pi.
+//
+//                      1
+//    pi = 3 + ----------------- = [3; 7, 15, 1, 292, ...]
+//                        1
+//             7 + -------------
+//                          1
+//                 15 + -------
+//                       1 + ...
+//
+// A computed value x = pi * 10**places only tells that pi lies in the
+// interval [x, x+1) / 10**places. The numbers with a given first few terms
+// form an interval, so the terms shared by both ends of the interval are
+// terms of pi. Expanding both ends in parallel certifies every term until
+// they disagree, on average about one term per decimal digit.
+
+package pi
+
+import (
+    "context"
+    "math"
+    "math/big"
+)
+
+// Average number of decimal digits per continued fraction term for almost
+// all numbers (Levy's constant pi**2 / (6 ln 2 ln 10)), pi is no exception
+var digitsPerTerm = math.Pi * math.Pi / (6 * math.Ln2 * math.Ln10)
+
+// Return the terms of the simple continued fraction shared by all numbers
+// in the interval between lo and hi, but at most max terms (no limit if
+// max < 0). The order of lo and hi does not matter.
+func CertifiedTerms(lo, hi *big.Rat, max int) []*big.Int {
+    // Run the Euclidean algorithm on both ends p/q
+    p0, q0 := new(big.Int).Set(lo.Num()), new(big.Int).Set(lo.Denom())
+    p1, q1 := new(big.Int).Set(hi.Num()), new(big.Int).Set(hi.Denom())
+    r0, r1 := new(big.Int), new(big.Int)
+
+    var terms []*big.Int
+    for max < 0 || len(terms) < max {
+        a0, _ := new(big.Int).DivMod(p0, q0, r0)
+        a1, _ := new(big.Int).DivMod(p1, q1, r1)
+
+        // A zero remainder ends an expansion, its last term is ambiguous
+        // as [..., a] = [..., a-1, 1]
+        if a0.Cmp(a1) != 0 || r0.Sign() == 0 || r1.Sign() == 0 {
+            break
+        }
+        terms = append(terms, a0)
+
+        // p/q = a + r/q, continue with q/r
+        p0, q0, r0 = q0, r0, p0
+        p1, q1, r1 = q1, r1, p1
+    }
+    return terms
+}
+
+// Return the terms of pi's continued fraction certified by the value
+// x = pi * 10**places, assuming that x is correct. At most max terms are
+// returned, no limit if max < 0.
+func ContinuedFractionOf(x *big.Int, places, max int) []*big.Int {
+    scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+    lo := new(big.Rat).SetFrac(x, scale)
+    hi := new(big.Rat).SetFrac(new(big.Int).Add(x, big.NewInt(1)), scale)
+    return CertifiedTerms(lo, hi, max)
+}
+
+// Return the first terms of pi's continued fraction and the number of
+// decimal places computed to certify all of them. The precision is
+// increased until it suffices.
+func ContinuedFraction(ctx context.Context, terms int, opts *Options) (
+    []*big.Int, int, error) {
+    if opts != nil && opts.Base != 0 && opts.Base != 10 {
+        o := *opts
+        o.Base = 10
+        opts = &o
+    }
+
+    places := int(float64(terms)*digitsPerTerm*1.1) + 20
+    for {
+        x, err := Compute(ctx, places, opts)
+        if err != nil {
+            return nil, 0, err
+        }
+        cf := ContinuedFractionOf(x, places, terms)
+        if len(cf) >= terms {
+            return cf, places, nil
+        }
+        places += places / 2
+    }
+}
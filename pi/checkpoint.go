@@ -0,0 +1,213 @@
+// Checkpoints: the state of a computation saved to a file, from which a
+// later run continues, on the same machine or any other.
+//
+// The file is big endian throughout:
+//
+//    magic       8 bytes   "PICKPT\r\n"
+//    version     uint16    checkpointVersion
+//    algorithm   uint16    length n, then n bytes of the algorithm name
+//    terms       uint64    the state covers the terms [0, terms)
+//    count       uint16    number of integers of the state
+//    integers    count times: uint8 sign, 0 for >= 0 and 1 for < 0,
+//                uint64 length n, n bytes of the absolute value
+//    sha256      32 bytes  of everything before
+//
+// The Chudnovsky formula saves P, Q and T of the terms summed so far. It
+// sums the terms in a few chunks of binary splitting each and saves the
+// checkpoint after every chunk, replacing the file atomically. A later run
+// for more digits sums the missing terms only, extending the checkpoint.
+
+package pi
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/binary"
+    "errors"
+    "io"
+    "math/big"
+    "os"
+    "path/filepath"
+)
+
+const (
+    checkpointMagic   = "PICKPT\r\n"
+    checkpointVersion = 1
+
+    // Number of chunks a computation with a checkpoint is split into
+    checkpointChunks = 8
+)
+
+// The state of a computation saved in a checkpoint
+type Checkpoint struct {
+    Algorithm string     // the name of the algorithm, see Algorithms
+    Terms     int64      // the number of series terms summed
+    State     []*big.Int // for chudnovsky P, Q and T of the terms
+}
+
+// Return about the number of decimal places the terms of the checkpoint
+// are enough for, those a computation continuing from it gets without
+// summing more terms. 0 for a checkpoint of another algorithm.
+func (c *Checkpoint) Digits() int {
+    if c.Algorithm != "chudnovsky" || c.Terms <= 2 {
+        return 0
+    }
+    // The inverse of the number of terms in chudnovskySum
+    return int(float64(c.Terms-2) * chudnovskyDigitsPerTerm)
+}
+
+// Write the checkpoint in the format above
+func WriteCheckpoint(w io.Writer, c *Checkpoint) error {
+    var b bytes.Buffer
+    b.WriteString(checkpointMagic)
+    binary.Write(&b, binary.BigEndian, uint16(checkpointVersion))
+    binary.Write(&b, binary.BigEndian, uint16(len(c.Algorithm)))
+    b.WriteString(c.Algorithm)
+    binary.Write(&b, binary.BigEndian, uint64(c.Terms))
+    binary.Write(&b, binary.BigEndian, uint16(len(c.State)))
+    for _, x := range c.State {
+        sign := uint8(0)
+        if x.Sign() < 0 {
+            sign = 1
+        }
+        magnitude := x.Bytes()
+        b.WriteByte(sign)
+        binary.Write(&b, binary.BigEndian, uint64(len(magnitude)))
+        b.Write(magnitude)
+    }
+    sum := sha256.Sum256(b.Bytes())
+    b.Write(sum[:])
+    if _, err := w.Write(b.Bytes()); err != nil {
+        return errorf(ErrIO, "pi: writing checkpoint: %w", err)
+    }
+    return nil
+}
+
+// Read a checkpoint written by WriteCheckpoint. A file that is not a
+// checkpoint, is truncated or fails the hash is ErrCorruptCheckpoint, one
+// of a later version ErrUnsupported.
+func ReadCheckpoint(r io.Reader) (*Checkpoint, error) {
+    data, err := io.ReadAll(r)
+    if err != nil {
+        return nil, errorf(ErrIO, "pi: reading checkpoint: %w", err)
+    }
+    corrupt := func(what string) error {
+        return errorf(ErrCorruptCheckpoint, "pi: corrupt checkpoint: %s",
+            what)
+    }
+    if len(data) < len(checkpointMagic)+sha256.Size ||
+        string(data[:len(checkpointMagic)]) != checkpointMagic {
+        return nil, corrupt("no checkpoint")
+    }
+    body := data[:len(data)-sha256.Size]
+    if sum := sha256.Sum256(body); !bytes.Equal(sum[:],
+        data[len(body):]) {
+        return nil, corrupt("hash mismatch")
+    }
+
+    b := bytes.NewReader(body[len(checkpointMagic):])
+    var version, nameLen uint16
+    if err := binary.Read(b, binary.BigEndian, &version); err != nil {
+        return nil, corrupt("truncated")
+    }
+    if version != checkpointVersion {
+        return nil, errorf(ErrUnsupported, "pi: checkpoint version %d, "+
+            "this build reads version %d", version, checkpointVersion)
+    }
+    if err := binary.Read(b, binary.BigEndian, &nameLen); err != nil {
+        return nil, corrupt("truncated")
+    }
+    name := make([]byte, nameLen)
+    if _, err := io.ReadFull(b, name); err != nil {
+        return nil, corrupt("truncated")
+    }
+    var terms uint64
+    var count uint16
+    if binary.Read(b, binary.BigEndian, &terms) != nil ||
+        binary.Read(b, binary.BigEndian, &count) != nil {
+        return nil, corrupt("truncated")
+    }
+    c := &Checkpoint{Algorithm: string(name), Terms: int64(terms)}
+    for i := 0; i < int(count); i++ {
+        var sign uint8
+        var n uint64
+        if binary.Read(b, binary.BigEndian, &sign) != nil || sign > 1 ||
+            binary.Read(b, binary.BigEndian, &n) != nil ||
+            n > uint64(b.Len()) {
+            return nil, corrupt("invalid integer")
+        }
+        magnitude := make([]byte, n)
+        io.ReadFull(b, magnitude)
+        x := new(big.Int).SetBytes(magnitude)
+        if sign == 1 {
+            x.Neg(x)
+        }
+        c.State = append(c.State, x)
+    }
+    if b.Len() != 0 || c.Terms < 0 {
+        return nil, corrupt("trailing data")
+    }
+    return c, nil
+}
+
+// Return the checkpoint in the named file, nil if there is no file
+func loadCheckpoint(name string) (*Checkpoint, error) {
+    f, err := os.Open(name)
+    if errors.Is(err, os.ErrNotExist) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, errorf(ErrIO, "pi: %w", err)
+    }
+    defer f.Close()
+    return ReadCheckpoint(bufio.NewReader(f))
+}
+
+// Replace the named file by the checkpoint: written to a temporary file
+// in the same directory first, so that an interrupted write leaves the
+// previous checkpoint in place
+func saveCheckpoint(name string, c *Checkpoint) error {
+    f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+    if err != nil {
+        return errorf(ErrIO, "pi: %w", err)
+    }
+    defer os.Remove(f.Name())
+    w := bufio.NewWriter(f)
+    err = WriteCheckpoint(w, c)
+    if err == nil {
+        err = w.Flush()
+    }
+    if err == nil {
+        err = f.Sync()
+    }
+    if cerr := f.Close(); err == nil {
+        err = cerr
+    }
+    if err == nil {
+        err = os.Rename(f.Name(), name)
+    }
+    if err != nil && !errors.Is(err, ErrIO) {
+        err = errorf(ErrIO, "pi: %w", err)
+    }
+    return err
+}
+
+// The context key of the checkpoint file of a computation
+type checkpointKey struct{}
+
+// Return ctx carrying the name of the checkpoint file of the computation
+func withCheckpoint(ctx context.Context, name string) context.Context {
+    return context.WithValue(ctx, checkpointKey{}, name)
+}
+
+// Check that the options compute pi with the Chudnovsky formula, the only
+// computation with checkpoints
+func (opts *Options) checkCheckpoint() error {
+    if opts.Expr == "" && (opts.Constant == "" || opts.Constant == "pi") &&
+        opts.algorithm() == "chudnovsky" {
+        return nil
+    }
+    return errorf(ErrUnsupported, "pi: checkpoints need pi with chudnovsky")
+}
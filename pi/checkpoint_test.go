@@ -0,0 +1,138 @@
+package pi
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/binary"
+    "errors"
+    "math/big"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func testCheckpoint() *Checkpoint {
+    big1, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+    return &Checkpoint{
+        Algorithm: "chudnovsky",
+        Terms:     42,
+        State:     []*big.Int{big1, big.NewInt(0), big.NewInt(-7)},
+    }
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+    var b bytes.Buffer
+    c := testCheckpoint()
+    if err := WriteCheckpoint(&b, c); err != nil {
+        t.Fatal(err)
+    }
+    got, err := ReadCheckpoint(&b)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got.Algorithm != c.Algorithm || got.Terms != c.Terms ||
+        len(got.State) != len(c.State) {
+        t.Fatalf("got %+v, want %+v", got, c)
+    }
+    for i, x := range c.State {
+        if got.State[i].Cmp(x) != 0 {
+            t.Fatalf("integer %d: got %v, want %v", i, got.State[i], x)
+        }
+    }
+}
+
+func TestCheckpointDamaged(t *testing.T) {
+    var b bytes.Buffer
+    if err := WriteCheckpoint(&b, testCheckpoint()); err != nil {
+        t.Fatal(err)
+    }
+    data := b.Bytes()
+
+    flipped := bytes.Clone(data)
+    flipped[len(flipped)/2] ^= 1
+    for name, damaged := range map[string][]byte{
+        "flipped byte": flipped,
+        "truncated":    data[:len(data)-1],
+        "header only":  data[:len(checkpointMagic)+2],
+        "empty":        nil,
+    } {
+        _, err := ReadCheckpoint(bytes.NewReader(damaged))
+        if !errors.Is(err, ErrCorruptCheckpoint) {
+            t.Errorf("%s: %v, want ErrCorruptCheckpoint", name, err)
+        }
+    }
+
+    // A later version with a valid hash
+    later := bytes.Clone(data[:len(data)-sha256.Size])
+    binary.BigEndian.PutUint16(later[len(checkpointMagic):],
+        checkpointVersion+1)
+    sum := sha256.Sum256(later)
+    later = append(later, sum[:]...)
+    if _, err := ReadCheckpoint(bytes.NewReader(later)); !errors.Is(err,
+        ErrUnsupported) {
+        t.Errorf("later version: %v, want ErrUnsupported", err)
+    }
+}
+
+func TestCheckpointResume(t *testing.T) {
+    const places = 20000
+    name := filepath.Join(t.TempDir(), "pi.ckpt")
+    want, err := Compute(context.Background(), places, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // Interrupted half way through the terms
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    opts := &Options{Algorithm: "chudnovsky", Checkpoint: name,
+        Progress: func(p Progress) {
+            if p.Fraction() >= 0.5 {
+                cancel()
+            }
+        }}
+    if _, err := Compute(ctx, places, opts); !errors.Is(err,
+        context.Canceled) {
+        t.Fatalf("interrupted run: %v", err)
+    }
+    f, err := os.Open(name)
+    if err != nil {
+        t.Fatal(err)
+    }
+    c, err := ReadCheckpoint(f)
+    f.Close()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if c.Terms == 0 || c.Digits() >= places {
+        t.Fatalf("checkpoint of %d terms after the interruption", c.Terms)
+    }
+
+    // Continued, then extended to more digits
+    opts.Progress = nil
+    for _, n := range []int{places, 2 * places} {
+        got, err := Compute(context.Background(), n, opts)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if n != places {
+            want, _ = Compute(context.Background(), n, nil)
+        }
+        if got.Cmp(want) != 0 {
+            t.Fatalf("%d places from the checkpoint differ", n)
+        }
+    }
+}
+
+func TestCheckpointIO(t *testing.T) {
+    dir := t.TempDir()
+    if _, err := loadCheckpoint(dir); !errors.Is(err, ErrIO) {
+        t.Errorf("loading a directory: %v, want ErrIO", err)
+    }
+    name := filepath.Join(dir, "missing", "pi.ckpt")
+    if err := saveCheckpoint(name, testCheckpoint()); !errors.Is(err,
+        ErrIO) {
+        t.Errorf("saving into a missing directory: %v, want ErrIO", err)
+    }
+}
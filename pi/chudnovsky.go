@@ -0,0 +1,227 @@
+// The Chudnovsky formula, evaluated by binary splitting.
+//
+//     1      12   inf  (-1)**k (6k)! (13591409 + 545140134k)
+//    --  = ------  sum  -----------------------------------------
+//    pi    640320   k=0        (3k)! (k!)**3 640320**(3k+1/2)
+//
+// Rearranged as pi = 426880 * sqrt(10005) * Q(0, N) / T(0, N), where the
+// integers P, Q and T of a range of terms [a, b) are combined from the
+// halves [a, m) and [m, b):
+//
+//    P(a, b) = P(a, m) * P(m, b)
+//    Q(a, b) = Q(a, m) * Q(m, b)
+//    T(a, b) = T(a, m) * Q(m, b) + P(a, m) * T(m, b)
+//
+// Every term adds about 14.18 digits.
+
+package pi
+
+import (
+    "context"
+    "math"
+    "math/big"
+
+    "github.com/miromotl/pi_by_digits/pi/internal/bigint"
+)
+
+// Decimal digits gained per term of the Chudnovsky series
+var chudnovskyDigitsPerTerm = math.Log10(151931373056000)
+
+// 640320**3 / 24
+var chudnovskyC3Over24 = bigint.NewInt(10939058860032000)
+
+// Bound the error of chudnovsky: P, Q and T are exact but for the bits
+// beyond those of unity, truncated, the omitted tail is far below a unit
+// and the root of 10005 is off by less than a unit, which the factor
+// 426880 Q/T, about 0.03, shrinks
+func chudnovskyError(digits int) (*big.Float, *big.Float) {
+    return errorBounds(2, 2)
+}
+
+// Compute pi * unity with the Chudnovsky formula
+func chudnovsky(ctx context.Context, unity *big.Int, progress *tracker) (
+    *big.Int, error) {
+    q, t, err := chudnovskySum(ctx, unity, progress)
+    if err != nil {
+        return nil, err
+    }
+
+    // sqrt(10005) * unity
+    root := SqrtFixed(new(big.Int).Mul(unity, big.NewInt(10005)), unity)
+
+    // Q and T far exceed unity, their ratio needs its bits only
+    if shift := t.BitLen() - unity.BitLen() - 64; shift > 0 {
+        q = new(big.Int).Rsh(q, uint(shift))
+        t = new(big.Int).Rsh(t, uint(shift))
+    }
+
+    // pi * unity = 426880 * sqrt(10005) * unity * Q / T
+    pi := new(big.Int).Mul(q, big.NewInt(426880))
+    pi.Mul(pi, root)
+    pi.Quo(pi, t)
+
+    return pi, nil
+}
+
+// Bounds of pi * unity with the Chudnovsky formula: Q and T are exact, the
+// root lies within [root, root + 1] and the omitted terms change the result
+// by less than a unit
+func chudnovskyInterval(ctx context.Context, unity *big.Int,
+    progress *tracker) (lo, hi *big.Int, err error) {
+    q, t, err := chudnovskySum(ctx, unity, progress)
+    if err != nil {
+        return nil, nil, err
+    }
+    root := SqrtFixed(new(big.Int).Mul(unity, big.NewInt(10005)), unity)
+
+    factor := new(big.Int).Mul(q, big.NewInt(426880))
+    lo = new(big.Int).Mul(factor, root)
+    lo.Quo(lo, t)
+    lo.Sub(lo, big.NewInt(1))
+    hi = new(big.Int).Mul(factor, root.Add(root, big.NewInt(1)))
+    ceilQuo(hi, hi, t)
+    hi.Add(hi, big.NewInt(1))
+    return lo, hi, nil
+}
+
+// Return Q(0, N) and T(0, N) for enough terms N for unity
+func chudnovskySum(ctx context.Context, unity *big.Int, progress *tracker) (
+    q, t *big.Int, err error) {
+    terms := int64(float64(unityDigits(unity))/chudnovskyDigitsPerTerm) + 2
+    if name, _ := ctx.Value(checkpointKey{}).(string); name != "" {
+        return chudnovskyResume(ctx, name, terms, progress)
+    }
+    progress.expect(int(terms))
+
+    _, bq, bt, err := chudnovskySplit(ctx, 0, terms, progress)
+    if err != nil {
+        return nil, nil, err
+    }
+    progress.seriesDone()
+    return bq.Big(), bt.Big(), nil
+}
+
+// Same as chudnovskySum, continuing from the checkpoint in the named file
+// if there is one, and saving P, Q and T to it after every chunk of terms.
+// A checkpoint with more terms than needed is used as it is, the result
+// is just more precise.
+func chudnovskyResume(ctx context.Context, name string, terms int64,
+    progress *tracker) (q, t *big.Int, err error) {
+    c, err := loadCheckpoint(name)
+    if err != nil {
+        return nil, nil, err
+    }
+    if c == nil {
+        c = &Checkpoint{Algorithm: "chudnovsky"}
+    }
+    if c.Algorithm != "chudnovsky" || c.Terms > 0 && len(c.State) != 3 {
+        return nil, nil, errorf(ErrCorruptCheckpoint, "pi: checkpoint %s "+
+            "of %s is not one of chudnovsky", name, c.Algorithm)
+    }
+    progress.expect(int(max(terms-c.Terms, 0)))
+
+    var p, bq, bt *bigint.Int
+    if c.Terms > 0 {
+        p = bigint.FromBig(c.State[0])
+        bq = bigint.FromBig(c.State[1])
+        bt = bigint.FromBig(c.State[2])
+    }
+    chunk := (terms + checkpointChunks - 1) / checkpointChunks
+    for done := c.Terms; done < terms; done = min(done+chunk, terms) {
+        p2, q2, t2, err := chudnovskySplit(ctx, done, min(done+chunk, terms),
+            progress)
+        if err != nil {
+            return nil, nil, err
+        }
+        if p == nil {
+            p, bq, bt = p2, q2, t2
+        } else {
+            // As in chudnovskySplit, with [0, done) as left half
+            bt.Mul(bt, q2)
+            bt.Add(bt, t2.Mul(p, t2))
+            p.Mul(p, p2)
+            bq.Mul(bq, q2)
+        }
+        c.Terms = min(done+chunk, terms)
+        c.State = []*big.Int{p.Big(), bq.Big(), bt.Big()}
+        if err := saveCheckpoint(name, c); err != nil {
+            return nil, nil, err
+        }
+    }
+    progress.seriesDone()
+    return c.State[1], c.State[2], nil
+}
+
+// Return the magnitude of the term k of the series of 1/pi in digits,
+// -log10 of 12 (6k)! (13591409 + 545140134k) /
+// ((3k)! (k!)**3 640320**(3k+3/2)). The terms alternate and shrink, the
+// tail is below the next one.
+func chudnovskyMagnitude(k int64) float64 {
+    lgamma := func(x float64) float64 {
+        y, _ := math.Lgamma(x)
+        return y
+    }
+    n := float64(k)
+    ln := lgamma(6*n+1) - lgamma(3*n+1) - 3*lgamma(n+1) +
+        math.Log(12*(13591409+545140134*n)) - (3*n+1.5)*math.Log(640320)
+    return -ln / math.Ln10
+}
+
+// Return P, Q and T of the terms [a, b)
+func chudnovskySplit(ctx context.Context, a, b int64, progress *tracker) (
+    p, q, t *bigint.Int, err error) {
+    if b-a == 1 {
+        select {
+        case <-ctx.Done():
+            return nil, nil, nil, ctx.Err()
+        default:
+        }
+        progress.step()
+        if progress.tracing() {
+            progress.traceTerm(Term{
+                Series:    "chudnovsky",
+                Index:     int(a),
+                Magnitude: chudnovskyMagnitude(a),
+                Certified: chudnovskyMagnitude(a + 1),
+            })
+        }
+
+        if a == 0 {
+            p, q = bigint.NewInt(1), bigint.NewInt(1)
+        } else {
+            // P = (6a-5)(2a-1)(6a-1), Q = a**3 * 640320**3 / 24
+            p = bigint.NewInt(6*a - 5)
+            p.Mul(p, bigint.NewInt(2*a-1))
+            p.Mul(p, bigint.NewInt(6*a-1))
+            q = bigint.NewInt(a)
+            q.Mul(q, q).Mul(q, bigint.NewInt(a))
+            q.Mul(q, chudnovskyC3Over24)
+        }
+
+        // T = (-1)**a * P * (13591409 + 545140134a)
+        t = bigint.NewInt(545140134)
+        t.Mul(t, bigint.NewInt(a))
+        t.Add(t, bigint.NewInt(13591409))
+        t.Mul(t, p)
+        if a%2 == 1 {
+            t.Neg(t)
+        }
+        return p, q, t, nil
+    }
+
+    m := (a + b) / 2
+    p1, q1, t1, err := chudnovskySplit(ctx, a, m, progress)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+    p2, q2, t2, err := chudnovskySplit(ctx, m, b, progress)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+
+    t = t1.Mul(t1, q2)
+    t.Add(t, t2.Mul(p1, t2))
+    p = p1.Mul(p1, p2)
+    q = q1.Mul(q1, q2)
+    return p, q, t, nil
+}
@@ -0,0 +1,76 @@
+// Constants other than pi, computed with the same fixed point machinery.
+//
+// Every constant lives in a file of its own which registers it in init:
+//
+//    func init() {
+//        registerConstant(&constant{
+//            name:     "e",
+//            formula:  "e = sum(1/k!)",
+//            compute:  eSeries,
+//            maxError: eError,
+//        })
+//    }
+//
+// The compute function returns the constant times unity, maxError bounds
+// its error; Compute chooses the guard digits from the bound. The optional
+// bounds function carries lower and upper bounds through the formula for
+// ComputeInterval.
+
+package pi
+
+import (
+    "sort"
+)
+
+type constant struct {
+    name    string
+    formula string
+    compute  fixedFunc
+    maxError errorFunc
+    bounds   intervalFunc // nil if not supported
+}
+
+// The registry of constants, filled by the files defining them
+var constants = map[string]*constant{}
+
+// Add a constant to the registry, called from the init function of the
+// file defining it. Every name can be registered only once.
+func registerConstant(c *constant) {
+    if _, dup := constants[c.name]; dup || c.name == "pi" {
+        panic("pi: constant " + c.name + " registered twice")
+    }
+    constants[c.name] = c
+}
+
+// Return the names of the available constants in alphabetical order,
+// including pi and "sqrt:<n>" for the square root of any positive
+// integer n
+func Constants() []string {
+    names := []string{"pi", "sqrt:<n>"}
+    for name := range constants {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+func lookupConstant(name string) (*constant, error) {
+    c, ok := constants[name]
+    if !ok {
+        return sqrtConstant(name)
+    }
+    return c, nil
+}
+
+// Return the formula used for the named constant, for pi the one of the
+// named algorithm; "" if there is no such constant or algorithm
+func ConstantFormula(name, algorithm string) string {
+    if name == "" || name == "pi" {
+        return Formula(algorithm)
+    }
+    c, err := lookupConstant(name)
+    if err != nil {
+        return ""
+    }
+    return c.formula
+}
@@ -0,0 +1,180 @@
+// Decimal digit extraction with Plouffe's formula
+//
+//               inf  k 2**k        inf    k k!
+//    pi + 3 =  sum  --------  =  sum  ----------
+//              k=1  C(2k, k)     k=1  (2k - 1)!!
+//
+// and Bellard's algorithm: the denominators up to term N are products of
+// odd primes p < 2N, so the fractional part of 10**d * (pi + 3) is the sum
+// over these primes of the fractional parts of the partial sums modulo the
+// largest power m of p below 2N, with the factors p of the terms accounted
+// for separately. N is chosen so that the terms after it are smaller than
+// the digits delivered by a margin.
+//
+// The work grows with the square of the position, one pass over the N
+// terms for each prime, so a full computation is faster at any position;
+// extraction needs little memory, though. Like any truncated sum, the
+// result may be off by one unit in the last of the margin digits, which
+// carries into the delivered digits only if the margin digits are all 9s
+// or all 0s. DecimalDigits refuses to answer then.
+
+package pi
+
+import (
+    "math"
+    "math/bits"
+    "strings"
+)
+
+const (
+    // Maximum number of decimal digits delivered by DecimalDigits
+    MaxDecimalDigits = 20
+
+    // Digits computed beyond the delivered ones
+    decimalMargin = 12
+)
+
+// Return count decimal digits of pi starting at the given position,
+// position 1 being the first digit after the point: DecimalDigits(1, 5)
+// returns "14159". The digits before are not needed, but see above for
+// the cost and the caveat.
+func DecimalDigits(position int64, count int) (string, error) {
+    if position < 1 {
+        return "", errorf(ErrInvalidPrecision,
+            "pi: invalid decimal digit position %d", position)
+    }
+    if count < 0 || count > MaxDecimalDigits {
+        return "", errorf(ErrInvalidPrecision,
+            "pi: at most %d decimal digits at a time", MaxDecimalDigits)
+    }
+    // The moduli and their products have to fit in 64 bits
+    if position > 1<<28 {
+        return "", errorf(ErrInvalidPrecision,
+            "pi: decimal digit position %d too large", position)
+    }
+
+    // Terms shrink by about a factor of 2
+    places := position - 1 + int64(count) + decimalMargin
+    n := int64(math.Ceil(float64(places) * math.Log2(10)))
+
+    var f fraction192
+    for _, p := range oddPrimes(2 * n) {
+        f = f.add(plouffeSum(uint64(p), n, position-1))
+    }
+    digits := f.decimal(count + decimalMargin)
+    margin := digits[count:]
+    if strings.Trim(margin, "0") == "" || strings.Trim(margin, "9") == "" {
+        return "", errorf(ErrUndecided, "pi: decimal digits at %d too "+
+            "close to a rounding boundary, choose another count", position)
+    }
+    return digits[:count], nil
+}
+
+// Return the fractional part of 10**d times the part of the sum over the
+// first n terms belonging to the prime p
+func plouffeSum(p uint64, n, d int64) fraction192 {
+    // m = p**vmax, the largest power below 2n, powers[i] = p**i
+    powers := []uint64{1}
+    for powers[len(powers)-1]*p < uint64(2*n) {
+        powers = append(powers, powers[len(powers)-1]*p)
+    }
+    vmax := len(powers) - 1
+    m := powers[vmax]
+    if vmax == 0 {
+        return fraction192{}
+    }
+
+    // num = k! and den = (2k-1)!! without their factors p, v the number
+    // of factors p of the denominator of the term beyond those of the
+    // numerator. The sum is kept as sum/den, so that den is only inverted
+    // once at the end.
+    num, den, sum := uint64(1), uint64(1), uint64(0)
+    v := 0
+    for k := int64(1); k <= n; k++ {
+        t := uint64(k)
+        for t%p == 0 {
+            t /= p
+            v--
+        }
+        num = num * t % m
+
+        t = uint64(2*k - 1)
+        for t%p == 0 {
+            t /= p
+            v++
+        }
+        den = den * t % m
+        sum = sum * t % m
+
+        if v > 0 {
+            // k k! / (2k-1)!! as fraction with denominator p**vmax den
+            term := uint64(k) % m * num % m * powers[vmax-v] % m
+            sum = (sum + term) % m
+        }
+    }
+
+    s := mulMod(sum, invMod(den, m), m)
+    s = mulMod(s, powMod(10, d, m), m)
+    return ratio192(s, m)
+}
+
+// Return the odd primes below n
+func oddPrimes(n int64) []int64 {
+    composite := make([]bool, n)
+    var primes []int64
+    for i := int64(3); i < n; i += 2 {
+        if composite[i] {
+            continue
+        }
+        primes = append(primes, i)
+        for j := i * i; j < n; j += 2 * i {
+            composite[j] = true
+        }
+    }
+    return primes
+}
+
+// Return b**e mod m
+func powMod(b uint64, e int64, m uint64) uint64 {
+    result, base := uint64(1)%m, b%m
+    for ; e > 0; e >>= 1 {
+        if e&1 == 1 {
+            result = mulMod(result, base, m)
+        }
+        base = mulMod(base, base, m)
+    }
+    return result
+}
+
+// Return the inverse of a modulo m, a and m coprime
+func invMod(a, m uint64) uint64 {
+    // Extended Euclid on a, m
+    r0, r1 := int64(m), int64(a%m)
+    s0, s1 := int64(0), int64(1)
+    for r1 != 0 {
+        q := r0 / r1
+        r0, r1 = r1, r0-q*r1
+        s0, s1 = s1, s0-q*s1
+    }
+    if s0 < 0 {
+        s0 += int64(m)
+    }
+    return uint64(s0)
+}
+
+// Return the first count decimal digits of a
+func (a fraction192) decimal(count int) string {
+    digits := make([]byte, count)
+    for i := range digits {
+        // a * 10, the carry out of the top word is the next digit
+        var carry, hi, lo uint64
+        for j := 2; j >= 0; j-- {
+            hi, lo = bits.Mul64(a[j], 10)
+            var c uint64
+            a[j], c = bits.Add64(lo, carry, 0)
+            carry = hi + c
+        }
+        digits[i] = byte('0' + carry)
+    }
+    return string(digits)
+}
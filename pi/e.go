@@ -0,0 +1,112 @@
+// Euler's number e from the factorial series.
+//
+//         1    1    1    1
+//    e = -- + -- + -- + -- + ...
+//        0!   1!   2!   3!
+//
+// Every term follows from the previous one by a division by the next
+// integer, so the series costs about the same per term as arccot.
+
+package pi
+
+import (
+    "context"
+    "math"
+    "math/big"
+)
+
+func init() {
+    registerConstant(&constant{
+        name:     "e",
+        formula:  "e = sum(1/k!)",
+        compute:  eSeries,
+        maxError: eError,
+        bounds:   eInterval,
+    })
+}
+
+// Compute e * unity with the factorial series
+func eSeries(ctx context.Context, unity *big.Int, progress *tracker) (
+    *big.Int, error) {
+    progress.expect(expectedFactorialTerms(unityDigits(unity)))
+
+    // sum = 1/0!, term = 1/0!
+    sum := new(big.Int).Set(unity)
+    term := new(big.Int).Set(unity)
+    k := big.NewInt(1)
+    one := big.NewInt(1)
+
+    // Compute successive terms until first term is 0
+    for {
+        select {
+        case <-ctx.Done():
+            return nil, ctx.Err()
+        default:
+        }
+
+        // term = term / k
+        term.Quo(term, k)
+        if term.Sign() == 0 {
+            break
+        }
+        sum.Add(sum, term)
+        k.Add(k, one)
+
+        progress.step()
+    }
+
+    return sum, nil
+}
+
+// Bounds of e * unity: the terms are computed rounded down and up, and the
+// omitted tail after a term t is below 2t
+func eInterval(ctx context.Context, unity *big.Int, progress *tracker) (
+    lo, hi *big.Int, err error) {
+    progress.expect(expectedFactorialTerms(unityDigits(unity)))
+
+    lo = new(big.Int).Set(unity)
+    hi = new(big.Int).Set(unity)
+    termLo := new(big.Int).Set(unity)
+    termHi := new(big.Int).Set(unity)
+    for k := int64(1); ; k++ {
+        select {
+        case <-ctx.Done():
+            return nil, nil, ctx.Err()
+        default:
+        }
+
+        kk := big.NewInt(k)
+        termLo.Quo(termLo, kk)
+        ceilQuo(termHi, termHi, kk)
+        if termLo.Sign() == 0 {
+            return lo, hi.Add(hi, termHi.Lsh(termHi, 1)), nil
+        }
+        lo.Add(lo, termLo)
+        hi.Add(hi, termHi)
+
+        progress.step()
+    }
+}
+
+// Bound the error of eSeries: every term is truncated, so the sum is low
+// by less than two units per term, including the omitted tail
+func eError(digits int) (*big.Float, *big.Float) {
+    return errorBounds(0, float64(2*(expectedFactorialTerms(digits)+1)+2))
+}
+
+// Estimate the number of terms of the factorial series for the given
+// number of digits: the smallest k with log10(k!) >= digits
+func expectedFactorialTerms(digits int) int {
+    k := 1
+    for {
+        lg, _ := math.Lgamma(float64(k + 1))
+        if lg/math.Ln10 >= float64(digits) {
+            return k
+        }
+        // Jump ahead while far away, the logarithm grows about k log10(k)
+        // per step of k
+        step := int((float64(digits) - lg/math.Ln10) /
+            (math.Log10(float64(k)) + 1))
+        k += max(step/2, 1)
+    }
+}
@@ -0,0 +1,68 @@
+// The errors of the package.
+//
+// Every error returned by a function of the package is one of the kinds
+// below, tested with errors.Is, e.g.
+//
+//    if errors.Is(err, pi.ErrInvalidPrecision) { ... }
+//
+// with a message of its own. A computation abandoned because its context
+// is done returns ctx.Err(), context.Canceled or context.DeadlineExceeded.
+
+package pi
+
+import (
+    "errors"
+    "fmt"
+)
+
+var (
+    // A negative number of places, a digit position or count out of
+    // range, or too few places for the request
+    ErrInvalidPrecision = errors.New("pi: invalid precision")
+
+    // A base other than 2 to 36
+    ErrInvalidBase = errors.New("pi: invalid base")
+
+    // An algorithm not in Algorithms or ExtractAlgorithms
+    ErrUnknownAlgorithm = errors.New("pi: unknown algorithm")
+
+    // A constant not in Constants, or an invalid sqrt:N
+    ErrUnknownConstant = errors.New("pi: unknown constant")
+
+    // An expression that does not parse, or divides by zero
+    ErrInvalidExpression = errors.New("pi: invalid expression")
+
+    // Options the computation does not support, e.g. bounds for an
+    // expression or Checkpoint for Machin's formula
+    ErrUnsupported = errors.New("pi: unsupported options")
+
+    // Digits that cannot be decided with the precision of the method
+    ErrUndecided = errors.New("pi: undecided digits")
+
+    // A checkpoint file that is damaged or not a checkpoint at all
+    ErrCorruptCheckpoint = errors.New("pi: corrupt checkpoint")
+
+    // A failure to read or write a checkpoint, wrapping the error of the
+    // file or the io.Reader or io.Writer
+    ErrIO = errors.New("pi: i/o error")
+)
+
+// An error of one of the kinds above with a message of its own
+type kindError struct {
+    kind error
+    err  error
+}
+
+// Return an error of the given kind with a message formatted by
+// fmt.Errorf, which may wrap the cause with %w
+func errorf(kind error, format string, args ...interface{}) error {
+    return &kindError{kind: kind, err: fmt.Errorf(format, args...)}
+}
+
+func (e *kindError) Error() string {
+    return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+    return []error{e.kind, e.err}
+}
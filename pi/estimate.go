@@ -0,0 +1,142 @@
+// Predicting the cost of a computation without doing it.
+//
+// The running time is calibrated by timing the same computation, digits
+// included, for doubling numbers of places, and extrapolated with the
+// growth exponent of these runs. The memory follows from the number
+// of integers the size of the result a formula keeps alive at its peak.
+
+package pi
+
+import (
+    "context"
+    "math"
+    "runtime"
+    "strings"
+    "time"
+)
+
+// The predicted cost of a computation
+type Cost struct {
+    Memory uint64        // peak heap in bytes
+    Time   time.Duration // wall time, including the conversion to digits
+}
+
+const (
+    // Places of the first calibration run of Estimate
+    estimateStartPlaces = 1000
+
+    // Calibration stops once a run takes this long
+    estimateCalibration = 300 * time.Millisecond
+
+    // Memory of the Go runtime and the program itself
+    estimateBaseMemory = 8 << 20
+)
+
+// Return the predicted cost of Compute and FormatBase for the given number
+// of places and options. The prediction is rough, expect it to be off by
+// a factor of two. Fewer places than the calibration would take are just
+// computed and measured.
+func Estimate(ctx context.Context, places int, opts *Options) (Cost, error) {
+    if opts == nil {
+        opts = &Options{}
+    }
+    base := opts.Base
+    if base == 0 {
+        base = 10
+    }
+
+    // Time runs of doubling size until they are long enough to measure,
+    // the first one not dominated by the overhead sets the growth
+    var firstPlaces int
+    var first, last time.Duration
+    p := min(estimateStartPlaces, places)
+    for {
+        start := time.Now()
+        x, err := Compute(ctx, p, opts)
+        if err != nil {
+            return Cost{}, err
+        }
+        FormatBase(x, p, base)
+        last = time.Since(start)
+        if firstPlaces == 0 && last >= estimateCalibration/16 {
+            firstPlaces, first = p, last
+        }
+        if p == places || p >= 4*firstPlaces && last >= estimateCalibration {
+            break
+        }
+        p = min(2*p, places)
+    }
+
+    cost := Cost{Time: last, Memory: EstimateMemory(places, opts)}
+    if p < places {
+        // Between linear and quadratic, whatever the noise says
+        exponent := math.Log(float64(last)/float64(first)) /
+            math.Log(float64(p)/float64(firstPlaces))
+        exponent = math.Max(1, math.Min(exponent, 2.2))
+        scale := math.Pow(float64(places)/float64(p), exponent)
+        cost.Time = time.Duration(float64(last) * scale)
+    }
+    return cost, nil
+}
+
+// Return the predicted peak heap of Compute and FormatBase for the given
+// number of places and options, the part of Estimate that needs no
+// calibration
+func EstimateMemory(places int, opts *Options) uint64 {
+    if opts == nil {
+        opts = &Options{}
+    }
+    base := opts.Base
+    if base == 0 {
+        base = 10
+    }
+    size := float64(places) * math.Log2(float64(base)) / 8
+
+    // The garbage collector lets the heap grow to twice the live integers,
+    // the digits are held as the converted buffer, the string and the
+    // string with the decimal point
+    live := 2 * opts.liveIntegers() * size
+    digits := 3 * float64(places)
+    return estimateBaseMemory + uint64(live+digits)
+}
+
+// Return the number of integers the size of the result the computation
+// keeps alive at its peak
+func (opts *Options) liveIntegers() float64 {
+    name := opts.Constant
+    if opts.Expr != "" || name == "" || name == "pi" {
+        name = opts.algorithm()
+    }
+
+    var live float64
+    switch name {
+    case "machin", "ln2", "ln10":
+        // The terms of every worker of the series, the results and the
+        // conversion to digits
+        live = 8 + 4*float64(min(runtime.GOMAXPROCS(0), maxInterleave))
+    case "chudnovsky":
+        // P, Q and T grow to a few times the size of the result, and so
+        // do the products combining them
+        live = 36
+    case "borwein":
+        // a, y and the temporaries of an iteration, and the square root
+        // of an integer twice their size
+        live = 10
+    case "sqrt2":
+        // Newton's iteration and its quotient
+        live = 6
+    default:
+        if strings.HasPrefix(name, "sqrt:") {
+            live = 6
+            break
+        }
+        // Binary splitting of a series: the sums of both halves and the
+        // products combining them
+        live = 12
+    }
+    if opts.Expr != "" {
+        // The powers and products of the expression
+        live += 4
+    }
+    return live
+}
@@ -0,0 +1,487 @@
+// Expressions in pi and the other constants, e.g. "2*pi", "pi^2/6" or
+// "2*pi + e^2", evaluated in fixed point arithmetic at the full precision
+// of the computation.
+//
+// The grammar, with the usual precedence and ^ binding to the right:
+//
+//    expr     = term {("+" | "-") term}
+//    term     = unary {("*" | "/") unary}
+//    unary    = "-" unary | power
+//    power    = atom ["^" ["-"] integer]
+//    atom     = number | constant | "(" expr ")"
+//    constant = "pi" | a name of Constants, e.g. e, ln2 or sqrt:5
+//
+// Numbers are decimal, with an optional fraction, e.g. 0.5. Every constant
+// is computed once at the precision of the whole. Every operation may be
+// off by a unit or two in the last place, and multiplies the errors of its
+// operands; the error bound follows them through the tree to first order,
+// starting from the error bounds of the constants, so that the guard
+// digits make up for what e.g. a division by a small value loses. The
+// approximate values of the nodes start at 64 bits; when a divisor cancels
+// to less than approxBits significant bits, e.g. in 1/(pi - 3.14159265359),
+// the bound starts over with twice as many.
+
+package pi
+
+import (
+    "context"
+    "fmt"
+    "math/big"
+    "slices"
+    "strconv"
+    "strings"
+)
+
+// Largest magnitude of an exponent
+const maxExprExponent = 1000
+
+// Significant bits of the approximate divisors of the error bound, and the
+// largest precision of the approximations
+const (
+    approxBits    = 16
+    maxApproxBits = 4096
+)
+
+// A node of the syntax tree of an expression
+type exprNode struct {
+    op          byte     // 'n' number, 'c' constant, 'u' minus, + - * / ^
+    left, right *exprNode
+    number      *big.Rat // the value for 'n'
+    name        string   // the constant for 'c'
+    exponent    int      // the exponent for '^'
+}
+
+// Check the syntax of the expression, the error describes the first
+// problem found
+func CheckExpr(expr string) error {
+    _, err := parseExpr(expr)
+    return err
+}
+
+// Return the computation of the expression with the given algorithm for pi
+// and its error bound
+func exprFunc(expr, algorithm string) (fixedFunc, errorFunc, error) {
+    tree, err := parseExpr(expr)
+    if err != nil {
+        return nil, nil, err
+    }
+    names := tree.constants(nil)
+    terms := make(map[string]*constant, len(names))
+    for _, name := range names {
+        c, err := exprConstant(name, algorithm)
+        if err != nil {
+            return nil, nil, err
+        }
+        terms[name] = c
+    }
+
+    // Approximate the constants, with more bits while a divisor cancels
+    var approx map[string]*big.Float
+    var bits uint
+    for bits = 64; ; bits *= 2 {
+        approx = make(map[string]*big.Float, len(names))
+        zero := make(map[string]*big.Float, len(names))
+        for _, name := range names {
+            if approx[name], err = approximate(terms[name], bits); err != nil {
+                return nil, nil, err
+            }
+            zero[name] = new(big.Float)
+        }
+        if _, _, ok := tree.bound(approx, zero, bits); ok ||
+            bits >= maxApproxBits {
+            break
+        }
+    }
+
+    compute := func(ctx context.Context, unity *big.Int, progress *tracker) (
+        *big.Int, error) {
+        values := make(map[string]*big.Int, len(names))
+        for _, name := range names {
+            x, err := terms[name].compute(ctx, unity, progress)
+            if err != nil {
+                return nil, err
+            }
+            values[name] = x
+        }
+        return tree.eval(unity, values)
+    }
+    maxError := func(digits int) (*big.Float, *big.Float) {
+        errs := make(map[string]*big.Float, len(names))
+        for _, name := range names {
+            below, above := terms[name].maxError(digits)
+            if below.Cmp(above) > 0 {
+                above = below
+            }
+            errs[name] = above
+        }
+        _, e, _ := tree.bound(approx, errs, bits)
+        return e, e
+    }
+    return compute, maxError, nil
+}
+
+// Return the named constant of an expression, pi with the given algorithm
+func exprConstant(name, algorithm string) (*constant, error) {
+    if name != "pi" {
+        return lookupConstant(name)
+    }
+    alg, err := lookupAlgorithm(algorithm)
+    if err != nil {
+        return nil, err
+    }
+    return &constant{
+        name:     "pi",
+        formula:  alg.formula,
+        compute:  alg.compute,
+        maxError: alg.maxError,
+    }, nil
+}
+
+// Return the constant to the given bits, for the error bound of an
+// expression
+func approximate(c *constant, bits uint) (*big.Float, error) {
+    unity := new(big.Int).Lsh(big.NewInt(1), bits)
+    x, err := c.compute(context.Background(), unity, nil)
+    if err != nil {
+        return nil, err
+    }
+    f := new(big.Float).SetPrec(bits).SetInt(x)
+    return f.SetMantExp(f, -int(bits)), nil
+}
+
+// Return the approximate value of the node and the bound of its error in
+// units, given the approximate values of the constants to the given bits
+// and their error bounds, and false if a divisor has less than approxBits
+// significant bits in that precision
+func (n *exprNode) bound(values map[string]*big.Float,
+    errs map[string]*big.Float, bits uint) (*big.Float, *big.Float, bool) {
+    f := func(x float64) *big.Float {
+        return new(big.Float).SetPrec(64).SetFloat64(x)
+    }
+    abs := func(x *big.Float) *big.Float {
+        return new(big.Float).Abs(x)
+    }
+
+    // |y| < 2**(approxBits - bits)
+    cancels := func(y *big.Float) bool {
+        return y.MantExp(nil) < approxBits-int(bits)
+    }
+
+    switch n.op {
+    case 'n':
+        return new(big.Float).SetPrec(bits).SetRat(n.number), f(1), true
+    case 'c':
+        return new(big.Float).Set(values[n.name]),
+            new(big.Float).SetPrec(64).Set(errs[n.name]), true
+    }
+
+    x, ex, ok := n.left.bound(values, errs, bits)
+    switch n.op {
+    case 'u':
+        return x.Neg(x), ex, ok
+    case '^':
+        if n.exponent == 0 {
+            return f(1), f(0), ok
+        }
+        // e = |dx**k/dx| ex + 2 = k |x|**(k-1) ex + 2
+        k := abs64(n.exponent)
+        power := f(1)
+        for i := 1; i < k; i++ {
+            power.Mul(power, x)
+        }
+        value := new(big.Float).Mul(power, x)
+        slope := abs(power)
+        if n.exponent < 0 {
+            // x**-k, the slope is k |x|**(-k-1); eval reports x = 0
+            if value.Sign() == 0 {
+                return f(0), f(0), false
+            }
+            ok = ok && !cancels(x)
+            value.Quo(f(1), value)
+            slope.Quo(f(1), slope.Mul(slope, x).Mul(slope, x))
+        }
+        e := slope.Mul(slope, ex)
+        e.Mul(e, f(float64(k)))
+        return value, e.Add(e, f(2)), ok
+    }
+
+    y, ey, rok := n.right.bound(values, errs, bits)
+    ok = ok && rok
+    e := new(big.Float).SetPrec(64)
+    switch n.op {
+    case '+':
+        return x.Add(x, y), e.Add(ex, ey), ok
+    case '-':
+        return x.Sub(x, y), e.Add(ex, ey), ok
+    case '*':
+        // e = |x| ey + |y| ex + 2
+        e.Mul(abs(x), ey)
+        e.Add(e, new(big.Float).Mul(abs(y), ex))
+        return x.Mul(x, y), e.Add(e, f(2)), ok
+    default:
+        // e = (ex + |x/y| ey) / |y| + 2, eval reports y = 0
+        if y.Sign() == 0 {
+            return f(0), f(0), false
+        }
+        q := new(big.Float).Quo(x, y)
+        e.Mul(abs(q), ey)
+        e.Add(e, ex)
+        e.Quo(e, abs(y))
+        return q, e.Add(e, f(2)), ok && !cancels(y)
+    }
+}
+
+func abs64(x int) int {
+    if x < 0 {
+        return -x
+    }
+    return x
+}
+
+// Append the names of the constants of the node not in names yet
+func (n *exprNode) constants(names []string) []string {
+    if n == nil {
+        return names
+    }
+    if n.op == 'c' && !slices.Contains(names, n.name) {
+        names = append(names, n.name)
+    }
+    return n.right.constants(n.left.constants(names))
+}
+
+// Return the value of the node times unity, given the values of the
+// constants times unity
+func (n *exprNode) eval(unity *big.Int, values map[string]*big.Int) (
+    *big.Int, error) {
+    switch n.op {
+    case 'n':
+        x := new(big.Int).Mul(n.number.Num(), unity)
+        return x.Quo(x, n.number.Denom()), nil
+    case 'c':
+        return new(big.Int).Set(values[n.name]), nil
+    }
+
+    x, err := n.left.eval(unity, values)
+    if err != nil {
+        return nil, err
+    }
+    switch n.op {
+    case 'u':
+        return x.Neg(x), nil
+    case '^':
+        return power(x, n.exponent, unity)
+    }
+
+    y, err := n.right.eval(unity, values)
+    if err != nil {
+        return nil, err
+    }
+    switch n.op {
+    case '+':
+        x.Add(x, y)
+    case '-':
+        x.Sub(x, y)
+    case '*':
+        x.Mul(x, y)
+        x.Quo(x, unity)
+    case '/':
+        if y.Sign() == 0 {
+            return nil, errorf(ErrInvalidExpression, "pi: division by zero")
+        }
+        x.Mul(x, unity)
+        x.Quo(x, y)
+    }
+    return x, nil
+}
+
+// Return x**e in fixed point arithmetic: x**e / unity**(e-1)
+func power(x *big.Int, e int, unity *big.Int) (*big.Int, error) {
+    if e == 0 {
+        return new(big.Int).Set(unity), nil
+    }
+    if e < 0 {
+        if x.Sign() == 0 {
+            return nil, errorf(ErrInvalidExpression, "pi: division by zero")
+        }
+        // unity**(1-e) / x**(-e)
+        num := new(big.Int).Exp(unity, big.NewInt(int64(1-e)), nil)
+        return num.Quo(num, new(big.Int).Exp(x, big.NewInt(int64(-e)), nil)),
+            nil
+    }
+    p := new(big.Int).Exp(x, big.NewInt(int64(e)), nil)
+    return p.Quo(p, new(big.Int).Exp(unity, big.NewInt(int64(e-1)), nil)),
+        nil
+}
+
+// Recursive descent parser, pos is the byte offset in s
+type exprParser struct {
+    s   string
+    pos int
+}
+
+func parseExpr(s string) (*exprNode, error) {
+    p := &exprParser{s: s}
+    n, err := p.expr()
+    if err != nil {
+        return nil, err
+    }
+    if p.skip(); p.pos < len(p.s) {
+        return nil, p.errorf("unexpected %q", p.s[p.pos])
+    }
+    return n, nil
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+    return errorf(ErrInvalidExpression,
+        "pi: invalid expression %q at offset %d: %s", p.s, p.pos,
+        fmt.Sprintf(format, args...))
+}
+
+// Skip white space
+func (p *exprParser) skip() {
+    for p.pos < len(p.s) && strings.IndexByte(" \t\n", p.s[p.pos]) >= 0 {
+        p.pos++
+    }
+}
+
+// Consume c if it is the next character
+func (p *exprParser) accept(c byte) bool {
+    p.skip()
+    if p.pos < len(p.s) && p.s[p.pos] == c {
+        p.pos++
+        return true
+    }
+    return false
+}
+
+func (p *exprParser) expr() (*exprNode, error) {
+    left, err := p.term()
+    if err != nil {
+        return nil, err
+    }
+    for {
+        op := byte('+')
+        if !p.accept('+') {
+            if !p.accept('-') {
+                return left, nil
+            }
+            op = '-'
+        }
+        right, err := p.term()
+        if err != nil {
+            return nil, err
+        }
+        left = &exprNode{op: op, left: left, right: right}
+    }
+}
+
+func (p *exprParser) term() (*exprNode, error) {
+    left, err := p.unary()
+    if err != nil {
+        return nil, err
+    }
+    for {
+        op := byte('*')
+        if !p.accept('*') {
+            if !p.accept('/') {
+                return left, nil
+            }
+            op = '/'
+        }
+        right, err := p.unary()
+        if err != nil {
+            return nil, err
+        }
+        left = &exprNode{op: op, left: left, right: right}
+    }
+}
+
+func (p *exprParser) unary() (*exprNode, error) {
+    if p.accept('-') {
+        n, err := p.unary()
+        if err != nil {
+            return nil, err
+        }
+        return &exprNode{op: 'u', left: n}, nil
+    }
+    return p.power()
+}
+
+func (p *exprParser) power() (*exprNode, error) {
+    base, err := p.atom()
+    if err != nil {
+        return nil, err
+    }
+    if !p.accept('^') {
+        return base, nil
+    }
+
+    negative := p.accept('-')
+    p.skip()
+    start := p.pos
+    for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+        p.pos++
+    }
+    e, err := strconv.Atoi(p.s[start:p.pos])
+    if err != nil || e > maxExprExponent {
+        p.pos = start
+        return nil, p.errorf("need an integer exponent up to %d",
+            maxExprExponent)
+    }
+    if negative {
+        e = -e
+    }
+    return &exprNode{op: '^', left: base, exponent: e}, nil
+}
+
+func (p *exprParser) atom() (*exprNode, error) {
+    p.skip()
+    if p.pos == len(p.s) {
+        return nil, p.errorf("unexpected end")
+    }
+
+    if p.accept('(') {
+        n, err := p.expr()
+        if err != nil {
+            return nil, err
+        }
+        if !p.accept(')') {
+            return nil, p.errorf("missing )")
+        }
+        return n, nil
+    }
+
+    if c := p.s[p.pos]; c >= 'a' && c <= 'z' {
+        return p.constant()
+    }
+
+    start := p.pos
+    for p.pos < len(p.s) && (p.s[p.pos] >= '0' && p.s[p.pos] <= '9' ||
+        p.s[p.pos] == '.') {
+        p.pos++
+    }
+    number, ok := new(big.Rat).SetString(p.s[start:p.pos])
+    if start == p.pos || !ok {
+        p.pos = start
+        return nil, p.errorf("expected a number, a constant or (")
+    }
+    return &exprNode{op: 'n', number: number}, nil
+}
+
+// Parse the name of a constant, letters and digits, and a colon for
+// sqrt:<n>
+func (p *exprParser) constant() (*exprNode, error) {
+    start := p.pos
+    for p.pos < len(p.s) && (p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z' ||
+        p.s[p.pos] >= '0' && p.s[p.pos] <= '9' || p.s[p.pos] == ':') {
+        p.pos++
+    }
+    name := p.s[start:p.pos]
+    if name != "pi" {
+        if _, err := lookupConstant(name); err != nil {
+            p.pos = start
+            return nil, p.errorf("unknown constant %q", name)
+        }
+    }
+    return &exprNode{op: 'c', name: name}, nil
+}
@@ -0,0 +1,35 @@
+package pi
+
+import (
+    "context"
+    "strings"
+    "testing"
+)
+
+func TestExpr(t *testing.T) {
+    for _, c := range []struct {
+        expr  string
+        round bool
+        want  string
+    }{
+        // Truncated towards zero, rounded away from it
+        {"-pi", false, "-3.1415926535"},
+        {"-pi", true, "-3.1415926536"},
+        {"1-pi", false, "-2.1415926535"},
+        // Error bounds beyond the range of float64
+        {"10^400", false, "1" + strings.Repeat("0", 400) + ".0000000000"},
+        {"e^1000", false, "1970071114017046993888879352243323125316"},
+        // A divisor that cancels in 64 bits
+        {"1/(pi - 3.14159265358979323846264338327950288)", false,
+            "238255811201922102438603910509311673.1585200365"},
+    } {
+        opts := &Options{Expr: c.expr, Round: c.round}
+        x, err := Compute(context.Background(), 10, opts)
+        if err != nil {
+            t.Fatalf("%s: %v", c.expr, err)
+        }
+        if got := Format(x, 10); !strings.HasPrefix(got, c.want) {
+            t.Errorf("%s: got %.50s, want %.50s", c.expr, got, c.want)
+        }
+    }
+}
@@ -0,0 +1,62 @@
+// The exponential function.
+//
+// The argument is reduced to r = x - k ln(2) with |r| <= ln(2)/2 and
+// halved a few more times, the Taylor series of the small remainder
+// converges fast, and squaring and shifting undo the reduction:
+//
+//    exp(x) = exp(r / 2**s)**(2**s) * 2**k
+
+package fixedmath
+
+import (
+    "math/big"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+// Number of halvings of the reduced argument, every one gains about a
+// bit per Taylor term and costs a bit of precision in the squaring
+const expHalvings = 16
+
+// Return exp(x / unity) * unity
+func Exp(x, unity *big.Int) *big.Int {
+    // A small unity has no bits of ln(2), take k from a guarded one; the
+    // factor 2**k may be small, keep its bits, too
+    xg, u := guard(x, unity, guardBits)
+    k := roundQuo(xg, pi.LnFixed(new(big.Int).Lsh(u, 1), u))
+    bits := uint(guardBits + expHalvings)
+    if k.Sign() < 0 && k.IsInt64() {
+        bits += uint(-k.Int64())
+    }
+    xg, u = guard(x, unity, bits)
+
+    // r = (x - k ln(2)) / 2**s
+    r := pi.LnFixed(new(big.Int).Lsh(u, 1), u)
+    r.Mul(r, k)
+    r.Sub(xg, r)
+    r.Quo(r, big.NewInt(1<<expHalvings))
+
+    // exp(r) = 1 + r + r**2/2! + ...
+    sum := new(big.Int).Set(u)
+    term := new(big.Int).Set(u)
+    for n := int64(1); ; n++ {
+        term = mul(term, r, u)
+        term.Quo(term, big.NewInt(n))
+        if term.Sign() == 0 {
+            break
+        }
+        sum.Add(sum, term)
+    }
+
+    for i := 0; i < expHalvings; i++ {
+        sum = mul(sum, sum, u)
+    }
+
+    // exp(x) = sum * 2**k
+    if k.Sign() >= 0 {
+        sum.Lsh(sum, uint(k.Uint64()))
+    } else {
+        sum.Rsh(sum, uint(-k.Int64()))
+    }
+    return unguard(sum, bits)
+}
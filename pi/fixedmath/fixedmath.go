@@ -0,0 +1,70 @@
+// Package fixedmath provides elementary functions in fixed point
+// arithmetic with arbitrary precision, on the scaled integers of package
+// pi: a real number y is represented by the integer x = y * unity for a
+// unity of your choice, e.g. 10**1000 for a thousand decimal places.
+//
+// All functions take the argument and the unity and return the result
+// with the same unity. They carry guard bits internally, the result is
+// off by at most a unit or two in the last place. Pi, the anchor of all
+// angles, comes from Machin's formula of package pi.
+package fixedmath
+
+import (
+    "math/big"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+// Bits carried beyond unity by every function to keep the rounding
+// errors of the series below the last unit
+const guardBits = 32
+
+// Return pi * unity: 16*arccot(5) - 4*arccot(239)
+func Pi(unity *big.Int) *big.Int {
+    x := pi.ArccotFixed(big.NewInt(5), unity)
+    x.Lsh(x, 4)
+    y := pi.ArccotFixed(big.NewInt(239), unity)
+    return x.Sub(x, y.Lsh(y, 2))
+}
+
+// Return ln(x / unity) * unity; Ln panics if x is not positive
+func Ln(x, unity *big.Int) *big.Int {
+    return pi.LnFixed(x, unity)
+}
+
+// Return arctan(x / unity) * unity, between -pi/2 and pi/2 times unity
+func Atan(x, unity *big.Int) *big.Int {
+    // The series of ArctanFixed round in the last place of their unity
+    y := pi.ArctanFixed(x, unity, new(big.Int).Lsh(unity, guardBits))
+    return unguard(y, guardBits)
+}
+
+// Return sqrt(x / unity) * unity; Sqrt panics if x is negative
+func Sqrt(x, unity *big.Int) *big.Int {
+    return pi.SqrtFixed(x, unity)
+}
+
+// Return x * 2**bits, and the new unity of the guarded representation
+func guard(x, unity *big.Int, bits uint) (*big.Int, *big.Int) {
+    return new(big.Int).Lsh(x, bits), new(big.Int).Lsh(unity, bits)
+}
+
+// Return x / 2**bits rounded down, removing the guard bits
+func unguard(x *big.Int, bits uint) *big.Int {
+    return x.Rsh(x, bits)
+}
+
+// Return x * y / unity
+func mul(x, y, unity *big.Int) *big.Int {
+    z := new(big.Int).Mul(x, y)
+    return z.Quo(z, unity)
+}
+
+// Return round(x / y)
+func roundQuo(x, y *big.Int) *big.Int {
+    q, r := new(big.Int).DivMod(x, y, new(big.Int))
+    if r.Lsh(r, 1).Cmp(y) >= 0 {
+        q.Add(q, big.NewInt(1))
+    }
+    return q
+}
@@ -0,0 +1,75 @@
+package fixedmath
+
+import (
+    "math/big"
+    "strings"
+    "testing"
+)
+
+// Return the decimal s times 10**places, truncated, and whether that is
+// exact
+func scaled(s string, places int) (*big.Int, bool) {
+    whole, frac, _ := strings.Cut(s, ".")
+    exact := len(strings.TrimRight(frac, "0")) <= places
+    frac += strings.Repeat("0", places)
+    x, _ := new(big.Int).SetString(whole+frac[:places], 10)
+    return x, exact
+}
+
+func TestFunctions(t *testing.T) {
+    funcs := map[string]func(x, unity *big.Int) *big.Int{
+        "Sin": Sin, "Cos": Cos, "Exp": Exp, "Ln": Ln, "Atan": Atan,
+    }
+    for _, c := range []struct {
+        f, x, want string
+    }{
+        {"Sin", "1",
+            "0.84147098480789650665250232163029899962256306079837"},
+        {"Sin", "-2.5",
+            "-0.59847214410395649405185470218616227170359717157722"},
+        {"Sin", "10",
+            "-0.54402111088936981340474766185137728168364301291622"},
+        {"Cos", "1",
+            "0.54030230586813971740093660744297660373231042061792"},
+        {"Cos", "-0.5",
+            "0.87758256189037271611628158260382965199164519710974"},
+        {"Cos", "100",
+            "0.86231887228768393410193851395084253551008400853551"},
+        {"Exp", "1",
+            "2.71828182845904523536028747135266249775724709369995"},
+        {"Exp", "-3",
+            "0.04978706836786394297934241565006177663169959218842"},
+        {"Exp", "10",
+            "22026.46579480671651695790064528424436635351261855678107"},
+        {"Exp", "0",
+            "1.00000000000000000000000000000000000000000000000000"},
+        {"Ln", "2",
+            "0.69314718055994530941723212145817656807550013436025"},
+        {"Ln", "0.5",
+            "-0.69314718055994530941723212145817656807550013436025"},
+        {"Ln", "10",
+            "2.30258509299404568401799145468436420760110148862877"},
+        {"Atan", "1",
+            "0.78539816339744830961566084581987572104929234984377"},
+        {"Atan", "0.5",
+            "0.46364760900080611621425623146121440202853705428612"},
+        {"Atan", "-3",
+            "-1.24904577239825442582991707728109012307782940412989"},
+    } {
+        for _, places := range []int{0, 1, 10, 50} {
+            x, exact := scaled(c.x, places)
+            if !exact {
+                continue
+            }
+            want, _ := scaled(c.want, places)
+            unity := new(big.Int).Exp(big.NewInt(10), big.NewInt(
+                int64(places)), nil)
+            got := funcs[c.f](x, unity)
+            // Off by at most a unit or two in the last place
+            if d := new(big.Int).Sub(got, want); d.CmpAbs(big.NewInt(2)) > 0 {
+                t.Errorf("%s(%s) to %d places: got %v, want %v", c.f, c.x,
+                    places, got, want)
+            }
+        }
+    }
+}
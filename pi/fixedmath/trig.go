@@ -0,0 +1,84 @@
+// Sine and cosine.
+//
+// The argument is reduced by multiples of pi/2 to r with |r| <= pi/4,
+// where the Taylor series of both functions converge fast; the quadrant
+// k of x = k pi/2 + r selects the result:
+//
+//    k mod 4     0        1        2        3
+//    sin(x)   sin(r)   cos(r)  -sin(r)  -cos(r)
+//    cos(x)   cos(r)  -sin(r)  -cos(r)   sin(r)
+
+package fixedmath
+
+import (
+    "math/big"
+)
+
+// Return sin(x / unity) * unity
+func Sin(x, unity *big.Int) *big.Int {
+    return sinCos(x, unity, 0)
+}
+
+// Return cos(x / unity) * unity
+func Cos(x, unity *big.Int) *big.Int {
+    return sinCos(x, unity, 1)
+}
+
+// Return sin(x + shift * pi/2) * unity
+func sinCos(x, unity *big.Int, shift int64) *big.Int {
+    // Every bit of the quadrant needs a bit of pi beyond unity, and a
+    // small unity needs guard bits to find the quadrant at all
+    xg, u := guard(x, unity, guardBits)
+    halfPi := Pi(u)
+    halfPi.Rsh(halfPi, 1)
+    k := roundQuo(xg, halfPi)
+    bits := uint(guardBits + k.BitLen())
+    xg, u = guard(x, unity, bits)
+
+    // r = x - k pi/2
+    halfPi = Pi(u)
+    halfPi.Rsh(halfPi, 1)
+    r := new(big.Int).Sub(xg, halfPi.Mul(halfPi, k))
+
+    quadrant := new(big.Int).Add(k, big.NewInt(shift))
+    var y *big.Int
+    switch new(big.Int).And(quadrant, big.NewInt(3)).Int64() {
+    case 0:
+        y = sinSeries(r, u)
+    case 1:
+        y = cosSeries(r, u)
+    case 2:
+        y = sinSeries(r, u)
+        y.Neg(y)
+    default:
+        y = cosSeries(r, u)
+        y.Neg(y)
+    }
+    return unguard(y, bits)
+}
+
+// sin(r) = r - r**3/3! + r**5/5! - ...
+func sinSeries(r, unity *big.Int) *big.Int {
+    return taylor(r, new(big.Int).Set(r), unity, 2)
+}
+
+// cos(r) = 1 - r**2/2! + r**4/4! - ...
+func cosSeries(r, unity *big.Int) *big.Int {
+    return taylor(r, new(big.Int).Set(unity), unity, 1)
+}
+
+// Sum the alternating series with the given first term, every next term
+// is the previous one times -r**2 / (n (n+1)) for n = first, first+2, ...
+func taylor(r, term, unity *big.Int, first int64) *big.Int {
+    square := mul(r, r, unity)
+    sum := new(big.Int).Set(term)
+    for n := first; ; n += 2 {
+        term = mul(term, square, unity)
+        term.Quo(term, big.NewInt(n*(n+1)))
+        if term.Sign() == 0 {
+            return sum
+        }
+        term.Neg(term)
+        sum.Add(sum, term)
+    }
+}
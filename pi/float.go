@@ -0,0 +1,52 @@
+// Pi as big.Float, correctly rounded to any precision.
+
+package pi
+
+import (
+    "context"
+    "math/big"
+)
+
+// Extra bits computed beyond the precision of Float, the first attempt
+// fails to decide the rounding in about one case out of 2**floatGuardBits
+const floatGuardBits = 32
+
+// Return pi rounded to prec bits of mantissa with the given rounding mode,
+// e.g. Float(53, big.ToNearestEven) is the float64 closest to pi. Unlike
+// the truncated digits of Fixed or a big.Float computation, the result is
+// correctly rounded in every bit. A prec of 0 is ErrInvalidPrecision.
+func Float(prec uint, mode big.RoundingMode) (*big.Float, error) {
+    if prec == 0 {
+        return nil, errorf(ErrInvalidPrecision, "pi: Float with zero "+
+            "precision")
+    }
+
+    // Compute pi with more fraction bits until both ends of the error
+    // interval round to the same float, pi is irrational so they do
+    // eventually
+    places := int(prec) + floatGuardBits
+    for {
+        x, err := Compute(context.Background(), places, &Options{Base: 2})
+        if err != nil {
+            return nil, err
+        }
+
+        // The result of Compute is at most a unit below pi * 2**places or
+        // a unit above, use two units for safety
+        lo := roundFixed(new(big.Int).Sub(x, big.NewInt(2)), places, prec,
+            mode)
+        hi := roundFixed(new(big.Int).Add(x, big.NewInt(2)), places, prec,
+            mode)
+        if lo.Cmp(hi) == 0 {
+            return lo, nil
+        }
+        places += places / 2
+    }
+}
+
+// Return x / 2**places rounded to prec bits
+func roundFixed(x *big.Int, places int, prec uint,
+    mode big.RoundingMode) *big.Float {
+    f := new(big.Float).SetPrec(prec).SetMode(mode).SetInt(x)
+    return f.SetMantExp(f, -places)
+}
@@ -0,0 +1,106 @@
+// The Euler-Mascheroni constant gamma with the Brent-McMillan algorithm.
+//
+// With the modified Bessel functions I0 and K0,
+//
+//    gamma = U/V - K0(2n)/I0(2n) - ln(n)
+//
+// where V = I0(2n) and U are power series in n**2, and the correction
+// K0/I0 is below pi * exp(-4n). Choosing n with exp(-4n) < unity**-1
+// leaves the quotient of the series, which are summed together:
+//
+//    B(0) = 1,      B(k) = B(k-1) * n**2 / k**2
+//    A(0) = -ln(n), A(k) = (A(k-1) * n**2 / k + B(k)) / k
+//
+//    gamma = sum(A(k)) / sum(B(k))
+//
+// The terms grow up to k = n and fall below a unit at about
+// k = 3.5911 n, where 3.5911 solves x (ln x - 1) = 3.
+
+package pi
+
+import (
+    "context"
+    "math"
+    "math/big"
+)
+
+func init() {
+    registerConstant(&constant{
+        name:     "gamma",
+        formula:  "gamma = U/V - ln(n) with the Brent-McMillan Bessel " +
+            "series U and V",
+        compute:  gamma,
+        maxError: gammaError,
+    })
+}
+
+// Number of terms of the Brent-McMillan series per unit of n
+const brentMcMillanTerms = 3.5911
+
+// Return the parameter n of the Brent-McMillan algorithm for the given
+// number of digits: exp(-4n) < 10**-digits
+func brentMcMillanN(digits int) int64 {
+    return int64(math.Ceil(float64(digits)*math.Ln10/4)) + 1
+}
+
+// Bound the error of gamma. B grows by the same factor n**2/k**2 as the
+// errors of its earlier terms, which keeps the relative error of B(k)
+// below 2k units of B(k)/unity; A(k) is about B(k) (H(k) - ln(n)) with an
+// error at most ln(n) + 3 times larger. The quotient U/V inherits the
+// relative errors, and the remainder K0/I0 is below a unit.
+func gammaError(digits int) (*big.Float, *big.Float) {
+    n := float64(brentMcMillanN(digits))
+    terms := brentMcMillanTerms * n
+    e := 8*terms*(math.Log(n)+3) + 8
+    return errorBounds(e, e)
+}
+
+// Compute gamma * unity with the Brent-McMillan algorithm
+func gamma(ctx context.Context, unity *big.Int, progress *tracker) (
+    *big.Int, error) {
+    n := brentMcMillanN(unityDigits(unity))
+    progress.expect(int(math.Ceil(brentMcMillanTerms * float64(n))))
+
+    square := big.NewInt(n * n)
+
+    // A = -ln(n), B = 1
+    a := LnFixed(new(big.Int).Mul(unity, big.NewInt(n)), unity)
+    a.Neg(a)
+    b := new(big.Int).Set(unity)
+    u := new(big.Int).Set(a)
+    v := new(big.Int).Set(b)
+
+    // Sum until both terms vanish past the maximum at k = n
+    for k := int64(1); ; k++ {
+        select {
+        case <-ctx.Done():
+            return nil, ctx.Err()
+        default:
+        }
+
+        kk := big.NewInt(k)
+
+        // B = B * n**2 / k**2
+        b.Mul(b, square)
+        b.Quo(b, kk)
+        b.Quo(b, kk)
+
+        // A = (A * n**2 / k + B) / k
+        a.Mul(a, square)
+        a.Quo(a, kk)
+        a.Add(a, b)
+        a.Quo(a, kk)
+
+        if k > n && a.Sign() == 0 && b.Sign() == 0 {
+            break
+        }
+        u.Add(u, a)
+        v.Add(v, b)
+
+        progress.step()
+    }
+
+    // gamma = U / V
+    u.Mul(u, unity)
+    return u.Quo(u, v), nil
+}
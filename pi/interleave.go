@@ -0,0 +1,114 @@
+// One series on several cores: interleaved partial sums of arccot.
+//
+// With K workers, worker j sums the terms j, j+K, j+2K, ... of the series.
+// Its powers of 1/x start at 1/x**(2j+1) and advance by the stride
+// 1/x**(2K) in a single division. Truncating division composes,
+// floor(floor(a/b)/c) = floor(a/(b*c)), so every worker computes exactly
+// the terms of the sequential loop, and the merged sum is identical.
+
+package pi
+
+import (
+    "context"
+    "math/big"
+    "runtime"
+    "sync"
+
+    "github.com/miromotl/pi_by_digits/pi/internal/bigint"
+)
+
+const (
+    // Series for fewer digits are not worth the goroutines
+    interleaveDigits = 1 << 14
+
+    // At most this many workers per series, the merge and the stride
+    // powers grow with them
+    maxInterleave = 16
+)
+
+// Return the number of workers for a series of unity, 1 for sequential
+func interleaveWorkers(unity *big.Int) int {
+    if unityDigits(unity) < interleaveDigits {
+        return 1
+    }
+    return min(runtime.GOMAXPROCS(0), maxInterleave)
+}
+
+// Same as arccotSeries with the terms spread over the given number of
+// workers
+func arccotInterleaved(ctx context.Context, x, unity *big.Int, sign2 int64,
+    workers int, progress *tracker) (*big.Int, error) {
+    square := new(big.Int).Mul(x, x)
+    stride := new(big.Int).Exp(square, big.NewInt(int64(workers)), nil)
+
+    sums := make([]*big.Int, workers)
+    errs := make([]error, workers)
+    var wg sync.WaitGroup
+    for j := 0; j < workers; j++ {
+        wg.Add(1)
+        go func(j int) {
+            defer wg.Done()
+
+            // power = unity / x**(2j+1)
+            power := new(big.Int).Exp(square, big.NewInt(int64(j)), nil)
+            power.Mul(power, x)
+            power.Quo(unity, power)
+            sums[j], errs[j] = arccotStride(ctx, unity, power, stride,
+                int64(j), int64(workers), sign2, progress)
+        }(j)
+    }
+    wg.Wait()
+
+    sum := big.NewInt(0)
+    for j := range sums {
+        if errs[j] != nil {
+            return nil, errs[j]
+        }
+        sum.Add(sum, sums[j])
+    }
+    return sum, nil
+}
+
+// Sum the terms i, i+k, i+2k, ... of the arccot series, given
+// first = unity / x**(2i+1) and stride = x**(2k)
+func arccotStride(ctx context.Context, unity, first, stride *big.Int, i, k,
+    sign2 int64, progress *tracker) (*big.Int, error) {
+    sum := bigint.NewInt(0)
+    power := bigint.FromBig(first)
+    term := bigint.NewInt(0)
+    next := bigint.NewInt(0)
+    div := bigint.FromBig(stride)
+    n := bigint.NewInt(2*i + 1)
+    step := bigint.NewInt(2 * k)
+
+    // The sign of term i is sign2**i, it flips with every stride of odd k
+    subtract := sign2 < 0 && i%2 == 1
+    flip := sign2 < 0 && k%2 == 1
+
+    for {
+        select {
+        case <-ctx.Done():
+            return nil, ctx.Err()
+        default:
+        }
+
+        term.Quo(power, n)
+        if term.Sign() == 0 {
+            return sum.Big(), nil
+        }
+        if subtract {
+            sum.Sub(sum, term)
+        } else {
+            sum.Add(sum, term)
+        }
+
+        next.Quo(power, div)
+        power, next = next, power
+        n.Add(n, step)
+        if flip {
+            subtract = !subtract
+        }
+
+        progress.step()
+    }
+}
@@ -0,0 +1,12 @@
+// Package bigint is the arithmetic layer of the series evaluation: an
+// arbitrary precision integer with the few operations the hot loops need.
+//
+// By default it wraps math/big. Built with the gmp tag it uses GMP
+// through cgo instead, which multiplies and divides huge operands a lot
+// faster:
+//
+//    go build -tags gmp
+//
+// The methods follow math/big: z.Op(x, y) sets z to the result and
+// returns z, and z may be one of the operands.
+package bigint
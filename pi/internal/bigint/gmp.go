@@ -0,0 +1,140 @@
+// The GMP backend, selected by the gmp build tag.
+
+//go:build gmp
+
+package bigint
+
+/*
+#cgo LDFLAGS: -lgmp
+#include <gmp.h>
+
+// mpz_sgn is a macro, cgo cannot call it
+static int bigint_sign(const mpz_t x) {
+    return mpz_sgn(x);
+}
+*/
+import "C"
+
+import (
+    "math/big"
+    "runtime"
+    "unsafe"
+)
+
+// Name of the arithmetic backend
+const Backend = "gmp"
+
+// An arbitrary precision integer, the zero value is 0. The limbs live in
+// C memory and are freed by a finalizer; an Int must not be copied.
+type Int struct {
+    v    C.mpz_t
+    init bool
+}
+
+// Return the mpz of z, initializing it on first use
+func (z *Int) mpz() *C.__mpz_struct {
+    if !z.init {
+        C.mpz_init(&z.v[0])
+        z.init = true
+        runtime.SetFinalizer(z, func(z *Int) {
+            C.mpz_clear(&z.v[0])
+        })
+    }
+    return &z.v[0]
+}
+
+// Return a new Int set to x
+func NewInt(x int64) *Int {
+    return new(Int).SetInt64(x)
+}
+
+// Return a new Int set to x
+func FromBig(x *big.Int) *Int {
+    return new(Int).SetBig(x)
+}
+
+func (z *Int) SetBig(x *big.Int) *Int {
+    words := x.Bits()
+    if len(words) == 0 {
+        return z.SetInt64(0)
+    }
+    // Least significant word first, native endianness
+    C.mpz_import(z.mpz(), C.size_t(len(words)), -1,
+        C.size_t(unsafe.Sizeof(words[0])), 0, 0, unsafe.Pointer(&words[0]))
+    if x.Sign() < 0 {
+        C.mpz_neg(z.mpz(), z.mpz())
+    }
+    return z
+}
+
+// Return the value of z as a new big.Int
+func (z *Int) Big() *big.Int {
+    x := new(big.Int)
+    n := C.mpz_size(z.mpz())
+    if n == 0 {
+        return x
+    }
+    words := make([]big.Word, n)
+    var count C.size_t
+    C.mpz_export(unsafe.Pointer(&words[0]), &count, -1,
+        C.size_t(unsafe.Sizeof(words[0])), 0, 0, z.mpz())
+    x.SetBits(words[:count])
+    if z.Sign() < 0 {
+        x.Neg(x)
+    }
+    runtime.KeepAlive(z)
+    return x
+}
+
+func (z *Int) Set(x *Int) *Int {
+    C.mpz_set(z.mpz(), x.mpz())
+    runtime.KeepAlive(x)
+    return z
+}
+
+func (z *Int) SetInt64(x int64) *Int {
+    C.mpz_set_si(z.mpz(), C.long(x))
+    return z
+}
+
+func (z *Int) Add(x, y *Int) *Int {
+    C.mpz_add(z.mpz(), x.mpz(), y.mpz())
+    runtime.KeepAlive(x)
+    runtime.KeepAlive(y)
+    return z
+}
+
+func (z *Int) Sub(x, y *Int) *Int {
+    C.mpz_sub(z.mpz(), x.mpz(), y.mpz())
+    runtime.KeepAlive(x)
+    runtime.KeepAlive(y)
+    return z
+}
+
+func (z *Int) Mul(x, y *Int) *Int {
+    C.mpz_mul(z.mpz(), x.mpz(), y.mpz())
+    runtime.KeepAlive(x)
+    runtime.KeepAlive(y)
+    return z
+}
+
+// Set z to x / y truncated towards zero
+func (z *Int) Quo(x, y *Int) *Int {
+    C.mpz_tdiv_q(z.mpz(), x.mpz(), y.mpz())
+    runtime.KeepAlive(x)
+    runtime.KeepAlive(y)
+    return z
+}
+
+func (z *Int) Neg(x *Int) *Int {
+    C.mpz_neg(z.mpz(), x.mpz())
+    runtime.KeepAlive(x)
+    return z
+}
+
+// Return -1, 0 or 1 for negative, zero or positive z
+func (z *Int) Sign() int {
+    s := int(C.bigint_sign(z.mpz()))
+    runtime.KeepAlive(z)
+    return s
+}
@@ -0,0 +1,83 @@
+// The pure Go backend of package math/big.
+
+//go:build !gmp
+
+package bigint
+
+import (
+    "math/big"
+)
+
+// Name of the arithmetic backend
+const Backend = "math/big"
+
+// An arbitrary precision integer, the zero value is 0
+type Int struct {
+    b big.Int
+
+    // The remainder discarded by Quo, kept to reuse its words
+    rest big.Int
+}
+
+// Return a new Int set to x
+func NewInt(x int64) *Int {
+    z := &Int{}
+    z.b.SetInt64(x)
+    return z
+}
+
+// Return a new Int set to x
+func FromBig(x *big.Int) *Int {
+    return new(Int).SetBig(x)
+}
+
+// Return the value of z as a new big.Int
+func (z *Int) Big() *big.Int {
+    return new(big.Int).Set(&z.b)
+}
+
+func (z *Int) Set(x *Int) *Int {
+    z.b.Set(&x.b)
+    return z
+}
+
+func (z *Int) SetBig(x *big.Int) *Int {
+    z.b.Set(x)
+    return z
+}
+
+func (z *Int) SetInt64(x int64) *Int {
+    z.b.SetInt64(x)
+    return z
+}
+
+func (z *Int) Add(x, y *Int) *Int {
+    z.b.Add(&x.b, &y.b)
+    return z
+}
+
+func (z *Int) Sub(x, y *Int) *Int {
+    z.b.Sub(&x.b, &y.b)
+    return z
+}
+
+func (z *Int) Mul(x, y *Int) *Int {
+    z.b.Mul(&x.b, &y.b)
+    return z
+}
+
+// Set z to x / y truncated towards zero
+func (z *Int) Quo(x, y *Int) *Int {
+    z.b.QuoRem(&x.b, &y.b, &z.rest)
+    return z
+}
+
+func (z *Int) Neg(x *Int) *Int {
+    z.b.Neg(&x.b)
+    return z
+}
+
+// Return -1, 0 or 1 for negative, zero or positive z
+func (z *Int) Sign() int {
+    return z.b.Sign()
+}
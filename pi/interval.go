@@ -0,0 +1,190 @@
+// Rigorous bounds: lower and upper fixed point bounds carried through every
+// operation of a formula, instead of an estimated error.
+//
+// Every division is done twice, rounding down for the lower and up for the
+// upper bound, subtractions pair the lower bound of one operand with the
+// upper bound of the other, and the omitted tail of a series widens the
+// bounds by a bound of its own.
+
+package pi
+
+import (
+    "context"
+    "math/big"
+)
+
+// Computes lower and upper bounds of a constant times unity
+type intervalFunc func(ctx context.Context, unity *big.Int,
+    progress *tracker) (lo, hi *big.Int, err error)
+
+// Return bounds of pi * base**places, or of the constant of opts: the
+// truncated value, or with opts.Round the rounded one, lies within
+// [lo, hi]. The bounds come from the operations of the formula and hold
+// without relying on an error estimate. The computation is repeated with
+// more guard digits while lo and hi differ, up to a few times. Only some
+// constants support bounds, for the others and for expressions
+// ComputeInterval returns an error.
+func ComputeInterval(ctx context.Context, places int, opts *Options) (
+    lo, hi *big.Int, err error) {
+    if places < 0 {
+        return nil, nil, errorf(ErrInvalidPrecision,
+            "pi: invalid number of places %d", places)
+    }
+    if opts == nil {
+        opts = &Options{}
+    }
+    bounds, maxError, err := opts.intervalFunc()
+    if err != nil {
+        return nil, nil, err
+    }
+    base := opts.Base
+    if base == 0 {
+        base = 10
+    }
+    if base < 2 || base > 36 {
+        return nil, nil, errorf(ErrInvalidBase, "pi: invalid base %d", base)
+    }
+
+    guard, err := guardDigits(maxError, places, base)
+    if err != nil {
+        return nil, nil, err
+    }
+    b := big.NewInt(int64(base))
+    for retry := 0; ; retry++ {
+        unity := new(big.Int).Exp(b, big.NewInt(int64(places+guard)), nil)
+        progress := newTracker(places, opts)
+        lo, hi, err = bounds(ctx, unity, progress)
+        if err != nil {
+            return nil, nil, err
+        }
+
+        // Remove the guard digits from both bounds
+        scale := new(big.Int).Exp(b, big.NewInt(int64(guard)), nil)
+        half := big.NewInt(0)
+        if opts.Round {
+            half.Rsh(scale, 1)
+        }
+        lo.Div(lo.Add(lo, half), scale)
+        hi.Div(hi.Add(hi, half), scale)
+        progress.finish(opts.Timing)
+
+        if lo.Cmp(hi) == 0 || retry == maxGuardRetries {
+            return lo, hi, nil
+        }
+        guard *= 2
+    }
+}
+
+// Return the bounds of the computation selected by the options and the
+// error estimate choosing their guard digits
+func (opts *Options) intervalFunc() (intervalFunc, errorFunc, error) {
+    if opts.Expr != "" {
+        return nil, nil, errorf(ErrUnsupported, "pi: no bounds for expressions")
+    }
+    if opts.Constant == "" || opts.Constant == "pi" {
+        alg, err := lookupAlgorithm(opts.algorithm())
+        if err != nil {
+            return nil, nil, err
+        }
+        if alg.bounds == nil {
+            return nil, nil, errorf(ErrUnsupported,
+                "pi: no bounds for algorithm %s", alg.name)
+        }
+        return alg.bounds, alg.maxError, nil
+    }
+
+    c, err := lookupConstant(opts.Constant)
+    if err != nil {
+        return nil, nil, err
+    }
+    if c.bounds == nil {
+        return nil, nil, errorf(ErrUnsupported, "pi: no bounds for %s", c.name)
+    }
+    return c.bounds, c.maxError, nil
+}
+
+// Bounds of pi * unity with Machin's formula:
+// 16*arccot(5) - 4*arccot(239)
+func machinInterval(ctx context.Context, unity *big.Int, progress *tracker) (
+    lo, hi *big.Int, err error) {
+    digits := unityDigits(unity)
+    progress.expect(expectedTerms(5, digits) + expectedTerms(239, digits))
+
+    lo5, hi5, err := arccotInterval(ctx, 5, unity, -1, progress)
+    if err != nil {
+        return nil, nil, err
+    }
+    lo239, hi239, err := arccotInterval(ctx, 239, unity, -1, progress)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    sixteen, four := big.NewInt(16), big.NewInt(4)
+    lo = new(big.Int).Mul(lo5, sixteen)
+    lo.Sub(lo, hi239.Mul(hi239, four))
+    hi = new(big.Int).Mul(hi5, sixteen)
+    hi.Sub(hi, lo239.Mul(lo239, four))
+    return lo, hi, nil
+}
+
+// Bounds of arccot(x) * unity, or of arccoth(x) * unity for sign2 = 1,
+// see arccotSeries
+func arccotInterval(ctx context.Context, x int64, unity *big.Int,
+    sign2 int64, progress *tracker) (lo, hi *big.Int, err error) {
+    square := big.NewInt(x * x)
+
+    // The powers 1/x**n, rounded down and up
+    powerLo := new(big.Int).Quo(unity, big.NewInt(x))
+    powerHi := new(big.Int).Add(powerLo, big.NewInt(1))
+    lo = new(big.Int).Set(powerLo)
+    hi = new(big.Int).Set(powerHi)
+
+    termLo, termHi := new(big.Int), new(big.Int)
+    positive := sign2 > 0
+    for n := int64(3); ; n += 2 {
+        select {
+        case <-ctx.Done():
+            return nil, nil, ctx.Err()
+        default:
+        }
+
+        powerLo.Quo(powerLo, square)
+        ceilQuo(powerHi, powerHi, square)
+        nn := big.NewInt(n)
+        termLo.Quo(powerLo, nn)
+        ceilQuo(termHi, powerHi, nn)
+
+        if termLo.Sign() == 0 {
+            // The terms fall, so the tail of the alternating series is
+            // smaller than its first term; for arccoth the tail is a
+            // geometric series of ratio 1/x**2 at most
+            if positive {
+                hi.Add(hi, termHi.Lsh(termHi, 1))
+            } else {
+                lo.Sub(lo, termHi)
+                hi.Add(hi, termHi)
+            }
+            return lo, hi, nil
+        }
+
+        if positive {
+            lo.Add(lo, termLo)
+            hi.Add(hi, termHi)
+        } else {
+            lo.Sub(lo, termHi)
+            hi.Sub(hi, termLo)
+        }
+        if sign2 < 0 {
+            positive = !positive
+        }
+
+        progress.step()
+    }
+}
+
+// Set z = ceil(x / y) for non-negative x and positive y and return z
+func ceilQuo(z, x, y *big.Int) *big.Int {
+    z.Add(x, y)
+    z.Sub(z, big.NewInt(1))
+    return z.Quo(z, y)
+}
@@ -0,0 +1,163 @@
+// Natural logarithms in fixed point arithmetic.
+//
+// ln 2 and ln 10 are Machin-like sums of the hyperbolic arccotangent,
+// the series of arccot without the alternating signs,
+//
+//    arccoth(x) = atanh(1/x) = 1/2 ln((x+1)/(x-1))
+//
+//    ln 2  = 14 arccoth(31) + 10 arccoth(49) +  6 arccoth(161)
+//    ln 10 = 46 arccoth(31) + 34 arccoth(49) + 20 arccoth(161)
+//
+// A general logarithm is reduced to ln 2 and the atanh series of an
+// argument between -1/3 and 1/3:
+//
+//    ln(x) = k ln(2) + 2 atanh((m-1) / (m+1)),  x = m * 2**k
+
+package pi
+
+import (
+    "context"
+    "math/big"
+)
+
+func init() {
+    registerConstant(&constant{
+        name:     "ln2",
+        formula:  "ln(2) = 14*arccoth(31) + 10*arccoth(49) + 6*arccoth(161)",
+        compute:  ln2,
+        maxError: arccothSumError([3]int64{14, 10, 6}),
+        bounds:   arccothSumInterval([3]int64{14, 10, 6}),
+    })
+    registerConstant(&constant{
+        name:     "ln10",
+        formula:  "ln(10) = 46*arccoth(31) + 34*arccoth(49) + 20*arccoth(161)",
+        compute:  ln10,
+        maxError: arccothSumError([3]int64{46, 34, 20}),
+        bounds:   arccothSumInterval([3]int64{46, 34, 20}),
+    })
+}
+
+// Extra bits carried by LnFixed to keep the rounding errors of its
+// series below the last unit
+const lnGuardBits = 32
+
+// Compute ln(2) * unity
+func ln2(ctx context.Context, unity *big.Int, progress *tracker) (
+    *big.Int, error) {
+    return arccothSum(ctx, unity, progress, [3]int64{14, 10, 6})
+}
+
+// Compute ln(10) * unity
+func ln10(ctx context.Context, unity *big.Int, progress *tracker) (
+    *big.Int, error) {
+    return arccothSum(ctx, unity, progress, [3]int64{46, 34, 20})
+}
+
+// Compute a*arccoth(31) + b*arccoth(49) + c*arccoth(161) times unity for
+// the factors {a, b, c}
+func arccothSum(ctx context.Context, unity *big.Int, progress *tracker,
+    factors [3]int64) (*big.Int, error) {
+    args := [3]int64{31, 49, 161}
+
+    digits := unityDigits(unity)
+    for _, x := range args {
+        progress.expect(expectedTerms(x, digits))
+    }
+
+    sum := big.NewInt(0)
+    for i, x := range args {
+        term, err := arccotSeries(ctx, big.NewInt(x), unity, 1, progress)
+        if err != nil {
+            return nil, err
+        }
+        sum.Add(sum, term.Mul(term, big.NewInt(factors[i])))
+    }
+    return sum, nil
+}
+
+// Return the error bound of arccothSum with the given factors: the terms
+// are all positive and truncated, so the sum is low by at most the error
+// of arccot per series
+func arccothSumError(factors [3]int64) errorFunc {
+    return func(digits int) (*big.Float, *big.Float) {
+        e := 0.0
+        for i, x := range [3]int64{31, 49, 161} {
+            e += float64(factors[i]) * arccotError(x, digits)
+        }
+        return errorBounds(0, e)
+    }
+}
+
+// Return the bounds of arccothSum with the given factors
+func arccothSumInterval(factors [3]int64) intervalFunc {
+    return func(ctx context.Context, unity *big.Int, progress *tracker) (
+        lo, hi *big.Int, err error) {
+        digits := unityDigits(unity)
+        lo, hi = big.NewInt(0), big.NewInt(0)
+        for i, x := range [3]int64{31, 49, 161} {
+            progress.expect(expectedTerms(x, digits))
+            l, h, err := arccotInterval(ctx, x, unity, 1, progress)
+            if err != nil {
+                return nil, nil, err
+            }
+            f := big.NewInt(factors[i])
+            lo.Add(lo, l.Mul(l, f))
+            hi.Add(hi, h.Mul(h, f))
+        }
+        return lo, hi, nil
+    }
+}
+
+// Return ln(x) in fixed point arithmetic with the given unity, i.e.
+// ln(x / unity) * unity, e.g. LnFixed(2*unity, unity) is ln(2) * unity.
+// The result may be off by a unit in the last place. LnFixed panics if
+// x is not positive.
+func LnFixed(x, unity *big.Int) *big.Int {
+    if x.Sign() <= 0 {
+        panic("pi: logarithm of non-positive number")
+    }
+
+    // Carry guard bits through the series
+    u := new(big.Int).Lsh(unity, lnGuardBits)
+    xg := new(big.Int).Lsh(x, lnGuardBits)
+
+    // x = m * 2**k with m close to 1: compare x to unity * 2**k without
+    // losing any bits of x
+    k := xg.BitLen() - u.BitLen()
+    scaled, ref := xg, u
+    if k >= 0 {
+        ref = new(big.Int).Lsh(u, uint(k))
+    } else {
+        scaled = new(big.Int).Lsh(xg, uint(-k))
+    }
+
+    // y = (m-1) / (m+1)
+    y := new(big.Int).Sub(scaled, ref)
+    y.Mul(y, u)
+    y.Quo(y, new(big.Int).Add(scaled, ref))
+
+    // atanh(y) = y + y**3/3 + y**5/5 + ...
+    sum := new(big.Int).Set(y)
+    power := new(big.Int).Set(y)
+    square := new(big.Int).Mul(y, y)
+    square.Quo(square, u)
+    term := new(big.Int)
+    for n := int64(3); ; n += 2 {
+        power.Mul(power, square)
+        power.Quo(power, u)
+        term.Quo(power, big.NewInt(n))
+        if term.Sign() == 0 {
+            break
+        }
+        sum.Add(sum, term)
+    }
+    sum.Lsh(sum, 1)
+
+    if k != 0 {
+        l, _ := ln2(context.Background(), u, nil)
+        sum.Add(sum, l.Mul(l, big.NewInt(int64(k))))
+    }
+
+    // Floor division, ln is negative below unity
+    return sum.Rsh(sum, lnGuardBits)
+}
@@ -0,0 +1,503 @@
+// Package pi computes pi with arbitrary precision using Machin's formula.
+// Algorithm is taken from:
+// http://en.literateprograms.org/Pi_with_Machin%27s_formula_%28Python%29
+
+package pi
+
+import (
+    "context"
+    "math"
+    "math/big"
+
+    "github.com/miromotl/pi_by_digits/pi/internal/bigint"
+)
+
+// Return pi with the given number of decimal places as a string,
+// e.g. Digits(5) returns "3.14159"
+func Digits(places int) string {
+    return Format(Fixed(places), places)
+}
+
+// Same as Digits, but gives up with ctx.Err() when ctx is done before
+// the computation completes
+func DigitsCtx(ctx context.Context, places int) (string, error) {
+    x, err := Compute(ctx, places, nil)
+    if err != nil {
+        return "", err
+    }
+    return Format(x, places), nil
+}
+
+// Return the fixed point number x = y * 10**places as decimal string of y,
+// e.g. Format(314159, 5) returns "3.14159". The conversion runs in parallel,
+// for huge numbers it is a lot faster than x.String().
+func Format(x *big.Int, places int) string {
+    return FormatBase(x, places, 10)
+}
+
+// Same as Format for x = y * base**places, the digits of y are written in
+// the given base, 2 to 36, with lower case letters for the digits above 9
+func FormatBase(x *big.Int, places, base int) string {
+    s := radixString(x, base)
+    if places <= 0 {
+        return s
+    }
+
+    sign := ""
+    if s[0] == '-' {
+        sign, s = "-", s[1:]
+    }
+    for len(s) <= places {
+        s = "0" + s
+    }
+    point := len(s) - places
+    return sign + s[:point] + "." + s[point:]
+}
+
+// Return pi scaled by 10**places and truncated to an integer,
+// e.g. Fixed(5) returns 314159; negative places count as 0
+func Fixed(places int) *big.Int {
+    x, _ := Compute(context.Background(), max(places, 0), nil)
+    return x
+}
+
+// Name of the arithmetic the series run on: "math/big", or "gmp" when
+// built with -tags gmp
+const Backend = bigint.Backend
+
+// Options control a computation, a nil *Options selects the defaults
+type Options struct {
+    // Name of the constant to compute instead of pi, see Constants;
+    // empty for pi
+    Constant string
+
+    // Expression in pi and the other constants to compute instead, e.g.
+    // "pi^2/6" or "2*pi + e^2", see CheckExpr; empty for none
+    Expr string
+
+    // Name of the algorithm for pi, see Algorithms; empty or "auto" for
+    // the one of AutoAlgorithm
+    Algorithm string
+
+    // Base of the places, 2 to 36, the result is pi * Base**places;
+    // 0 for base 10
+    Base int
+
+    // Round the last place to nearest instead of truncating, i.e. the
+    // result is the integer closest to pi * Base**places
+    Round bool
+
+    // Called after every evaluated series term, if not nil
+    Progress func(Progress)
+
+    // If not nil, the time spent by Compute is added to it
+    Timing *Timing
+
+    // Called after every term of the arccot series of Machin's formula,
+    // ln2 and ln10 and of the Chudnovsky series, in the order of the terms,
+    // if not nil. Tracing keeps every series on a single core; the other
+    // computations do not trace.
+    Trace func(Term)
+
+    // File the state of the computation is saved to while it runs, and
+    // continued from by the next computation with the same file; empty
+    // for none. Supported by the Chudnovsky formula, see Checkpoint.
+    Checkpoint string
+}
+
+// The guard digits push the error bound of a computation this many
+// decimal digits below the last place, so that only about one computation
+// in 10**certaintyDigits is undecided and needs to be repeated
+const certaintyDigits = 4
+
+// Return the number of guard digits in the given base for a computation
+// of the given number of places with the given error bound
+func guardDigits(maxError errorFunc, places, base int) (int, error) {
+    perPlace := math.Log10(float64(base))
+    guard := 1
+    // The bound grows with the digits of unity, a few rounds settle it
+    for i := 0; i < 4; i++ {
+        below, above := maxError(int(float64(places+guard)*perPlace) + 1)
+        e := above
+        if below.Cmp(above) > 0 {
+            e = below
+        }
+        if e.IsInf() {
+            return 0, errorf(ErrInvalidPrecision,
+                "pi: error bound out of range")
+        }
+        need := int(math.Ceil((errorDigits(e) + certaintyDigits) / perPlace))
+        if need <= guard {
+            break
+        }
+        guard = need
+    }
+    return guard, nil
+}
+
+// Return log10(2 e + 1), the decimal digits of the error bound e >= 0, which
+// may be beyond the range of float64
+func errorDigits(e *big.Float) float64 {
+    x := new(big.Float).SetMantExp(e, 1)
+    x.Add(x, big.NewFloat(1))
+    mant := new(big.Float)
+    exp := x.MantExp(mant)
+    m, _ := mant.Float64()
+    return math.Log10(m) + float64(exp)*math.Log10(2)
+}
+
+// Return the smallest number of places in the given base, 2 to 36, that
+// carries at least the given number of bits, e.g. PlacesForBits(53, 10)
+// returns 16
+func PlacesForBits(bits, base int) int {
+    if bits <= 0 {
+        return 0
+    }
+    // Bases that are powers of two carry a whole number of bits per place
+    if base&(base-1) == 0 {
+        perPlace := 0
+        for b := base; b > 1; b >>= 1 {
+            perPlace++
+        }
+        return (bits + perPlace - 1) / perPlace
+    }
+    return int(math.Ceil(float64(bits) / math.Log2(float64(base))))
+}
+
+// Same as Fixed, with options, e.g. for another constant or base. When
+// the guard digits leave the last place in doubt, the computation is
+// repeated with more of them. The computation is abandoned with ctx.Err()
+// as soon as ctx is done. A negative number of places is
+// ErrInvalidPrecision.
+func Compute(ctx context.Context, places int, opts *Options) (*big.Int,
+    error) {
+    x, _, err := ComputeCertified(ctx, places, opts)
+    return x, err
+}
+
+// Same as Compute, also returns how many of the places are guaranteed
+// correct by the error analysis of the formula: all of them unless the
+// value stays within the error bound of a boundary of the last place even
+// with more guard digits, e.g. for an exact square root.
+func ComputeCertified(ctx context.Context, places int, opts *Options) (
+    x *big.Int, correct int, err error) {
+    if places < 0 {
+        return nil, 0, errorf(ErrInvalidPrecision,
+            "pi: invalid number of places %d", places)
+    }
+    if opts == nil {
+        opts = &Options{}
+    }
+    compute, maxError, err := opts.fixedFunc()
+    if err != nil {
+        return nil, 0, err
+    }
+    if opts.Checkpoint != "" {
+        if err := opts.checkCheckpoint(); err != nil {
+            return nil, 0, err
+        }
+    }
+    base := opts.Base
+    if base == 0 {
+        base = 10
+    }
+    if base < 2 || base > 36 {
+        return nil, 0, errorf(ErrInvalidBase, "pi: invalid base %d", base)
+    }
+
+    guard, err := guardDigits(maxError, places, base)
+    if err != nil {
+        return nil, 0, err
+    }
+    for retry := 0; ; retry++ {
+        x, correct, err := computeFixed(ctx, compute, maxError, places, base,
+            guard, opts)
+        if err != nil {
+            return nil, 0, err
+        }
+        if correct == places || retry == maxGuardRetries {
+            return x, correct, nil
+        }
+        guard *= 2
+    }
+}
+
+// Number of times Compute doubles the guard digits when the last place
+// cannot be decided
+const maxGuardRetries = 3
+
+// Compute the constant with the given number of guard digits, and the
+// number of places that stay the same for any value within the error
+// bound
+func computeFixed(ctx context.Context, compute fixedFunc, maxError errorFunc,
+    places, base, guard int, opts *Options) (*big.Int, int, error) {
+    b := big.NewInt(int64(base))
+    
+    // Compute the unity scaling factor, add extra guard digits 
+    // to avoid rounding errors
+    // unity = base**(digits + guard), e.g. 10**(digits + 10)
+    unity := big.NewInt(0)
+    unity.Exp(b, big.NewInt(int64(places+guard)), nil)
+
+    if opts.Checkpoint != "" {
+        ctx = withCheckpoint(ctx, opts.Checkpoint)
+    }
+    
+    progress := newTracker(places, opts)
+    a, err := compute(ctx, unity, progress)
+    if err != nil {
+        return nil, 0, err
+    }
+
+    // The exact value lies within [a - below, a + above]
+    below, above := maxError(unityDigits(unity) + 1)
+    lo := new(big.Int).Sub(a, ceilInt(below))
+    hi := new(big.Int).Add(a, ceilInt(above))
+
+    // Remove the guard digits, rounding to nearest or truncating, towards
+    // zero for the negative values of expressions
+    // x = x / base**guard
+    scale := big.NewInt(0).Exp(b, big.NewInt(int64(guard)), nil)
+    half := big.NewInt(0)
+    if opts.Round {
+        half.Rsh(scale, 1)
+        if a.Sign() < 0 {
+            half.Neg(half)
+        }
+    }
+    // scale has a few words only, math/big divides by it in linear time:
+    // a reciprocal would cost a full multiplication for every quotient
+    for _, y := range []*big.Int{a, lo, hi} {
+        y.Add(y, half)
+        y.Quo(y, scale)
+    }
+
+    // Strip the differing trailing places off the bounds
+    correct := places
+    for lo.Cmp(hi) != 0 {
+        lo.Quo(lo, b)
+        hi.Quo(hi, b)
+        correct--
+    }
+    progress.finish(opts.Timing)
+    return a, max(correct, 0), nil
+}
+
+// Return x >= 0 rounded up to an integer
+func ceilInt(x *big.Float) *big.Int {
+    i, acc := x.Int(nil)
+    if acc == big.Below {
+        i.Add(i, big.NewInt(1))
+    }
+    return i
+}
+
+// Computes a constant times unity in fixed point arithmetic
+type fixedFunc func(ctx context.Context, unity *big.Int,
+    progress *tracker) (*big.Int, error)
+
+// Bounds the error of a fixedFunc: for unity with the given number of
+// decimal digits the exact value lies within [a - below, a + above] of
+// the result a
+type errorFunc func(digits int) (below, above *big.Float)
+
+// Return the error bounds of an errorFunc from float64 values
+func errorBounds(below, above float64) (*big.Float, *big.Float) {
+    return big.NewFloat(below), big.NewFloat(above)
+}
+
+// Return the computation selected by the options and its error bound
+func (opts *Options) fixedFunc() (fixedFunc, errorFunc, error) {
+    if opts.Expr != "" {
+        return exprFunc(opts.Expr, opts.algorithm())
+    }
+    if opts.Constant == "" || opts.Constant == "pi" {
+        alg, err := lookupAlgorithm(opts.algorithm())
+        if err != nil {
+            return nil, nil, err
+        }
+        return alg.compute, alg.maxError, nil
+    }
+
+    c, err := lookupConstant(opts.Constant)
+    if err != nil {
+        return nil, nil, err
+    }
+    return c.compute, c.maxError, nil
+}
+
+// Bound the error of machin: every arccot term is off by less than two
+// units, see arccotError
+func machinError(digits int) (*big.Float, *big.Float) {
+    e := 16*arccotError(5, digits) + 4*arccotError(239, digits)
+    return errorBounds(e, e)
+}
+
+// Compute pi * unity with Machin's formula
+func machin(ctx context.Context, unity *big.Int, progress *tracker) (*big.Int,
+    error) {
+    // Start approximation of pi with 4
+    pi := big.NewInt(4)
+    
+    digits := unityDigits(unity)
+    progress.expect(expectedTerms(5, digits) + expectedTerms(239, digits))
+    
+    // Machin's formula
+    // pi = 4 * (4 * arccot(5) - arccot(239))
+    
+    // Left part of Machin's formula
+    left, err := arccot(ctx, big.NewInt(5), unity, progress)
+    if err != nil {
+        return nil, err
+    }
+    left.Mul(left, big.NewInt(4))
+    
+    // Right part of Machin's formula
+    right, err := arccot(ctx, big.NewInt(239), unity, progress)
+    if err != nil {
+        return nil, err
+    }
+    
+    // Subtract right from left and save result in left
+    left.Sub(left, right)
+    
+    // Bring it all together to compute pi: pi = 4 * left
+    pi.Mul(pi, left)
+    
+    return pi, nil
+}
+
+// Compute arccot with a given precision
+//
+//             1     1     1     1
+// arccot(x) = -  - --- + --- - --- + ...
+//              1     3     5     7
+//             x    3x    5x    7x
+//
+// To calculate arccot of an argument x, we start by dividing the number 1 
+// (represented by 10n, which we provide as the argument unity) by x to obtain
+// the first term. We then repeatedly divide by x**2 and a counter value that 
+// runs over 3, 5, 7, ..., to obtain each next term. The summation is stopped 
+// at the first zero term, which in this fixed-point representation corresponds 
+// to a real value less than 10-n.
+//
+// The summation is abandoned as soon as ctx is done.
+
+func arccot(ctx context.Context, x, unity *big.Int,
+    progress *tracker) (*big.Int, error) {
+    return arccotSeries(ctx, x, unity, -1, progress)
+}
+
+// Sum the series of arccot with the given sign of the second term: -1 for
+// arccot, 1 for the hyperbolic arccoth, which differs in the signs only
+func arccotSeries(ctx context.Context, x, unity *big.Int, sign2 int64,
+    progress *tracker) (*big.Int, error) {
+    // Traced terms come in order from a single loop
+    if workers := interleaveWorkers(unity); workers > 1 &&
+        !progress.tracing() {
+        return arccotInterleaved(ctx, x, unity, sign2, workers, progress)
+    }
+    trace := arccotTracer(x, sign2, progress)
+
+    // Init sum with 1/x
+    sum := bigint.FromBig(unity)
+    sum.Quo(sum, bigint.FromBig(x))
+    
+    // Init xpower with 1/x
+    xpower := bigint.NewInt(0)
+    xpower.Set(sum)
+    trace(0)
+    
+    // Init n with 3, square with x*x and the temporaries reused by every
+    // term, the loop allocates nothing but the growth of their words
+    n := bigint.NewInt(3)
+    two := bigint.NewInt(2)
+    square := bigint.FromBig(x)
+    square.Mul(square, square)
+    term := bigint.NewInt(0)
+    next := bigint.NewInt(0)
+    subtract := sign2 < 0
+    k := 1
+    
+    // Compute successive terms until first term is 0
+    for {
+        select {
+        case <-ctx.Done():
+            return nil, ctx.Err()
+        default:
+        }
+        
+        // xpower = xpower / x*x, into the spare buffer: dividing in place
+        // would allocate. x*x is a word or two, the division takes linear
+        // time, unlike the multiplication by a reciprocal
+        next.Quo(xpower, square)
+        xpower, next = next, xpower
+        
+        //         1
+        // term = ---
+        //          n
+        //        nx
+        term.Quo(xpower, n)
+        
+        if term.Sign() == 0 {
+            break
+        }
+        
+        // sum = sum - term or sum + term, alternating for arccot
+        if subtract {
+            sum.Sub(sum, term)
+        } else {
+            sum.Add(sum, term)
+        }
+        
+        // Prepare for next iteration
+        // n = n + 2
+        if sign2 < 0 {
+            subtract = !subtract
+        }
+        n.Add(n, two)
+        
+        progress.step()
+        trace(k)
+        k++
+    }
+    
+    return sum.Big(), nil
+}
+
+// Return the function tracing the term k of the arccot series of x, or
+// arccoth for sign2 = 1, to the tracker if it traces
+func arccotTracer(x *big.Int, sign2 int64, progress *tracker) func(k int) {
+    if !progress.tracing() {
+        return func(int) {}
+    }
+    series := "arccot(" + x.String() + ")"
+    xf, _ := new(big.Float).SetInt(x).Float64()
+    logx := math.Log10(math.Abs(xf))
+    // The magnitude of the term 1/((2k+1) x**(2k+1)) in digits
+    magnitude := func(k int) float64 {
+        return math.Log10(float64(2*k+1)) + float64(2*k+1)*logx
+    }
+    // The alternating tail of arccot is below its first term, the tail of
+    // arccoth below the first term times x**2/(x**2-1)
+    tail := 0.0
+    if sign2 > 0 {
+        series = "arccoth(" + x.String() + ")"
+        tail = math.Log10(xf * xf / (xf*xf - 1))
+    }
+    return func(k int) {
+        progress.traceTerm(Term{
+            Series:    series,
+            Index:     k,
+            Magnitude: magnitude(k),
+            Certified: magnitude(k+1) - tail,
+        })
+    }
+}
+
+// The error of arccot: the first term and the powers of 1/x are off by less
+// than a unit, every term by less than two, and the omitted tail is below
+// two units
+func arccotError(x int64, digits int) float64 {
+    return float64(2*(expectedTerms(x, digits)+1) + 4)
+}
@@ -0,0 +1,29 @@
+package pi
+
+import (
+    "context"
+    "fmt"
+    "math/big"
+    "testing"
+)
+
+// The loop of arccotSeries: the number of allocations does not grow with
+// the number of terms
+func BenchmarkArccot(b *testing.B) {
+    ctx := context.Background()
+    for _, digits := range []int{1000, 10000} {
+        unity := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)),
+            nil)
+        for _, x := range []int64{5, 239} {
+            b.Run(fmt.Sprintf("%d/%d", x, digits), func(b *testing.B) {
+                b.ReportAllocs()
+                for i := 0; i < b.N; i++ {
+                    if _, err := arccot(ctx, big.NewInt(x), unity,
+                        nil); err != nil {
+                        b.Fatal(err)
+                    }
+                }
+            })
+        }
+    }
+}
@@ -0,0 +1,142 @@
+// Progress reporting for long computations.
+
+package pi
+
+import (
+    "math"
+    "math/big"
+    "sync"
+    "time"
+)
+
+// Progress describes how far the evaluation of the series has come
+type Progress struct {
+    Places        int // decimal places being computed
+    Terms         int // series terms evaluated so far
+    ExpectedTerms int // estimated total number of terms
+}
+
+// Return the estimated fraction of the work done, between 0 and 1.
+// The terms of the series all cost about the same, so this is a fair
+// estimate for the elapsed fraction of the running time, too.
+func (p Progress) Fraction() float64 {
+    if p.ExpectedTerms <= 0 {
+        return 0
+    }
+    return math.Min(float64(p.Terms)/float64(p.ExpectedTerms), 1)
+}
+
+// One term of a series, see Options.Trace. The terms so far certify the
+// digits of the series down to where the bound of the omitted tail starts.
+type Term struct {
+    Series    string  // e.g. "arccot(5)" or "chudnovsky"
+    Index     int     // of the term in its series, from 0
+    Magnitude float64 // size of the term in decimal digits, -log10 |term|
+    Certified float64 // digits certified, -log10 of the bound of the tail
+}
+
+// Where the time of a computation went
+type Timing struct {
+    Series      time.Duration // evaluating the series, up to the last term
+    Combination time.Duration // combining them into the result
+}
+
+// Counts the evaluated terms and forwards them to the progress callback,
+// safe for the workers of a series running concurrently. The time of the
+// last term ends the series, anything after is the combination.
+type tracker struct {
+    mu        sync.Mutex
+    callback  func(Progress)
+    trace     func(Term)
+    progress  Progress
+    start     time.Time
+    seriesEnd time.Time
+}
+
+// Return a tracker for the progress, timing and trace of opts, nil if they
+// ask for none
+func newTracker(places int, opts *Options) *tracker {
+    if opts.Progress == nil && opts.Timing == nil && opts.Trace == nil {
+        return nil
+    }
+    return &tracker{
+        callback: opts.Progress,
+        trace:    opts.Trace,
+        progress: Progress{Places: places},
+        start:    time.Now(),
+    }
+}
+
+// Add to the number of expected terms
+func (t *tracker) expect(terms int) {
+    if t == nil {
+        return
+    }
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    t.progress.ExpectedTerms += terms
+}
+
+// Account for one evaluated term
+func (t *tracker) step() {
+    if t == nil {
+        return
+    }
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    t.progress.Terms++
+    t.seriesEnd = time.Now()
+    if t.callback != nil {
+        t.callback(t.progress)
+    }
+}
+
+// Report whether the terms are traced, the series then call traceTerm for
+// every term in their order
+func (t *tracker) tracing() bool {
+    return t != nil && t.trace != nil
+}
+
+func (t *tracker) traceTerm(term Term) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    t.trace(term)
+}
+
+// Mark the end of the series where work on them continues after the last
+// term, e.g. binary splitting merging the halves
+func (t *tracker) seriesDone() {
+    if t == nil {
+        return
+    }
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    t.seriesEnd = time.Now()
+}
+
+// Add the time since the tracker was created to timing, if not nil
+func (t *tracker) finish(timing *Timing) {
+    if t == nil || timing == nil {
+        return
+    }
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    end := time.Now()
+    if t.seriesEnd.IsZero() {
+        t.seriesEnd = end
+    }
+    timing.Series += t.seriesEnd.Sub(t.start)
+    timing.Combination += end.Sub(t.seriesEnd)
+}
+
+// Estimate the number of terms arccot(x) needs for the given number of
+// digits. Every term is smaller than the previous one by a factor of
+// about x**2, i.e. the series converges with 2*log10(x) digits per term.
+func expectedTerms(x int64, digits int) int {
+    return int(math.Ceil(float64(digits) / (2 * math.Log10(float64(x)))))
+}
+
+// Return the number of decimal digits of the unity scaling factor
+func unityDigits(unity *big.Int) int {
+    return int(float64(unity.BitLen()) * math.Log10(2))
+}
@@ -0,0 +1,201 @@
+// Parallel conversion of huge big.Ints to decimal or any other base.
+//
+// fmt.Sprint on a big.Int with millions of digits runs on a single core and
+// easily takes longer than computing the digits themselves. Here we split the
+// number by powers of the base, e.g. 10**k, into a high and a low half and
+// convert the halves concurrently. The powers are obtained by repeated
+// squaring, so every split point is exactly representable and the low halves
+// only need to be padded with leading zeros.
+//
+// The same split tree walked from the most to the least significant leaf
+// gives a streaming conversion that never holds the complete string.
+
+package pi
+
+import (
+    "math/big"
+    "runtime"
+    "sync"
+)
+
+const (
+    // Numbers below this size in bits are converted with big.Int.Text
+    // directly, splitting them is not worth the effort
+    directConversionBits = 1 << 15
+
+    // Number of digits converted by big.Int.Text at the leaves of the
+    // recursion: base**leafDigits is the smallest split power
+    leafDigits = 1 << 10
+)
+
+// Return the representation of x in the given base, 2 to 36
+func radixString(x *big.Int, base int) string {
+    if x.Sign() < 0 {
+        return "-" + radixString(new(big.Int).Neg(x), base)
+    }
+
+    if x.BitLen() < directConversionBits {
+        return x.Text(base)
+    }
+
+    powers := splitPowers(x, base)
+
+    // x < powers[top] = powers[top-1]**2, so x has at most
+    // leafDigits * 2**top digits
+    top := len(powers) - 1
+    buf := make([]byte, leafDigits<<uint(top))
+
+    // Spawn goroutines for the upper levels of the recursion only, a few
+    // more pieces than cores keeps everybody busy
+    parallelDepth := 0
+    for n := runtime.GOMAXPROCS(0); n > 1; n >>= 1 {
+        parallelDepth++
+    }
+    if parallelDepth > 0 {
+        parallelDepth += 2
+    }
+
+    convertRadix(x, base, powers, top-1, buf, parallelDepth)
+
+    // Strip the leading zeros of the fixed width result
+    i := 0
+    for i < len(buf)-1 && buf[i] == '0' {
+        i++
+    }
+    return string(buf[i:])
+}
+
+// Return the split powers for x: powers[i] = base**(leafDigits * 2**i),
+// ending with the first power exceeding x
+func splitPowers(x *big.Int, base int) []*big.Int {
+    powers := []*big.Int{
+        new(big.Int).Exp(big.NewInt(int64(base)), big.NewInt(leafDigits), nil),
+    }
+    for powers[len(powers)-1].Cmp(x) <= 0 {
+        last := powers[len(powers)-1]
+        powers = append(powers, new(big.Int).Mul(last, last))
+    }
+    return powers
+}
+
+// Write x zero padded in the given base into buf. The caller guarantees
+// that x < powers[level]**2 and that len(buf) = 2 * leafDigits * 2**level.
+func convertRadix(x *big.Int, base int, powers []*big.Int, level int,
+    buf []byte, parallelDepth int) {
+    if level < 0 {
+        padRadix(buf, x, base)
+        return
+    }
+
+    // x = high * base**k + low
+    high, low := new(big.Int).QuoRem(x, powers[level], new(big.Int))
+    half := len(buf) / 2
+
+    if parallelDepth <= 0 {
+        convertRadix(high, base, powers, level-1, buf[:half], 0)
+        convertRadix(low, base, powers, level-1, buf[half:], 0)
+        return
+    }
+
+    var wg sync.WaitGroup
+    wg.Add(1)
+    go func() {
+        defer wg.Done()
+        convertRadix(high, base, powers, level-1, buf[:half],
+            parallelDepth-1)
+    }()
+    convertRadix(low, base, powers, level-1, buf[half:], parallelDepth-1)
+    wg.Wait()
+}
+
+// Write x right aligned into buf and fill the remainder with zeros
+func padRadix(buf []byte, x *big.Int, base int) {
+    s := x.Text(base)
+    pad := len(buf) - len(s)
+    for i := 0; i < pad; i++ {
+        buf[i] = '0'
+    }
+    copy(buf[pad:], s)
+}
+
+// A pending piece of the split tree: x < powers[level]**2 is split further,
+// a leaf at level -1 is padded to leafDigits and level -2 marks a number too
+// small to be split at all
+type radixPiece struct {
+    x     *big.Int
+    level int
+}
+
+// Sequential conversion of a non-negative big.Int producing the digits
+// chunk by chunk, most significant first
+type radixStream struct {
+    base    int
+    powers  []*big.Int
+    pending []radixPiece // stack, top is the next piece to convert
+    chunk   [leafDigits]byte
+    leading bool // still stripping leading zeros
+    empty   bool // nothing has been produced yet
+}
+
+func newRadixStream(x *big.Int, base int) *radixStream {
+    if x.Sign() < 0 {
+        panic("pi: radix stream of negative number")
+    }
+    s := &radixStream{base: base, leading: true, empty: true}
+    if x.BitLen() < directConversionBits {
+        // A single, unpadded piece
+        s.pending = []radixPiece{{x, -2}}
+        return s
+    }
+    s.powers = splitPowers(x, base)
+    s.pending = []radixPiece{{x, len(s.powers) - 2}}
+    return s
+}
+
+// Return the next chunk of digits, or nil when the conversion is complete.
+// The chunk is only valid until the following call.
+func (s *radixStream) next() []byte {
+    for len(s.pending) > 0 {
+        piece := s.pending[len(s.pending)-1]
+        s.pending = s.pending[:len(s.pending)-1]
+
+        if piece.level >= 0 {
+            // Split and push the low half first, so high is converted next
+            high, low := new(big.Int).QuoRem(piece.x, s.powers[piece.level],
+                new(big.Int))
+            s.pending = append(s.pending,
+                radixPiece{low, piece.level - 1},
+                radixPiece{high, piece.level - 1})
+            continue
+        }
+
+        var digits []byte
+        if piece.level == -2 {
+            digits = piece.x.Append(s.chunk[:0], s.base)
+        } else {
+            digits = s.chunk[:]
+            padRadix(digits, piece.x, s.base)
+        }
+
+        if s.leading {
+            i := 0
+            for i < len(digits) && digits[i] == '0' {
+                i++
+            }
+            digits = digits[i:]
+            if len(digits) == 0 {
+                continue
+            }
+            s.leading = false
+        }
+        s.empty = false
+        return digits
+    }
+
+    if s.empty {
+        // x is zero
+        s.empty = false
+        return []byte{'0'}
+    }
+    return nil
+}
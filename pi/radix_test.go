@@ -0,0 +1,50 @@
+package pi
+
+import (
+    "math/big"
+    "math/rand"
+    "testing"
+)
+
+func TestRadix(t *testing.T) {
+    r := rand.New(rand.NewSource(1))
+    for _, base := range []int{2, 7, 10, 16, 36} {
+        var xs []*big.Int
+        for _, bits := range []int{0, 1, 64, directConversionBits - 1,
+            directConversionBits, directConversionBits + 1,
+            5 * directConversionBits} {
+            // The largest number of the size and a random one
+            x := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+            xs = append(xs, new(big.Int).Sub(x, big.NewInt(1)),
+                new(big.Int).Rand(r, x))
+        }
+        // The split points and their neighbours
+        for _, p := range splitPowers(xs[len(xs)-1], base) {
+            xs = append(xs, p, new(big.Int).Sub(p, big.NewInt(1)),
+                new(big.Int).Add(p, big.NewInt(1)))
+        }
+
+        for _, x := range xs {
+            want := x.Text(base)
+            if got := radixString(x, base); got != want {
+                t.Errorf("base %d, %d bits: radixString differs", base,
+                    x.BitLen())
+            }
+            neg := new(big.Int).Neg(x)
+            if got := radixString(neg, base); got != neg.Text(base) {
+                t.Errorf("base %d, %d bits: radixString of the negative "+
+                    "differs", base, x.BitLen())
+            }
+
+            var got []byte
+            s := newRadixStream(x, base)
+            for chunk := s.next(); chunk != nil; chunk = s.next() {
+                got = append(got, chunk...)
+            }
+            if string(got) != want {
+                t.Errorf("base %d, %d bits: radixStream differs", base,
+                    x.BitLen())
+            }
+        }
+    }
+}
@@ -0,0 +1,113 @@
+// Rational approximations of pi from its continued fraction.
+//
+// The convergents p/q of the continued fraction, 3, 22/7, 333/106,
+// 355/113, ..., are the best approximations in the sense that no fraction
+// with a smaller denominator comes closer. The best approximation with a
+// denominator below some bound is either the last convergent within the
+// bound or one of the semiconvergents between it and the next convergent.
+
+package pi
+
+import (
+    "context"
+    "math"
+    "math/big"
+)
+
+// Return the convergents of the continued fraction cf
+func Convergents(cf []*big.Int) []*big.Rat {
+    p, q := convergentTerms(cf)
+    convergents := make([]*big.Rat, len(cf))
+    for k := range cf {
+        convergents[k] = new(big.Rat).SetFrac(p[k+2], q[k+2])
+    }
+    return convergents
+}
+
+// Return the numerators and denominators of the convergents, shifted by
+// two: p[0]/q[0] = 0/1 and p[1]/q[1] = 1/0 start the recurrence
+//
+//    p[k] = a[k] * p[k-1] + p[k-2], the same for q
+func convergentTerms(cf []*big.Int) (p, q []*big.Int) {
+    p = []*big.Int{big.NewInt(0), big.NewInt(1)}
+    q = []*big.Int{big.NewInt(1), big.NewInt(0)}
+    for k, a := range cf {
+        p = append(p, new(big.Int).Add(new(big.Int).Mul(a, p[k+1]), p[k]))
+        q = append(q, new(big.Int).Add(new(big.Int).Mul(a, q[k+1]), q[k]))
+    }
+    return p, q
+}
+
+// Return the best rational approximation with a denominator of at most
+// maxDen of the number whose continued fraction starts with cf. Returns nil
+// if cf is too short: it must reach at least two terms beyond the first
+// convergent with a denominator above maxDen.
+func BestApproximation(cf []*big.Int, maxDen *big.Int) *big.Rat {
+    p, q := convergentTerms(cf)
+
+    // The last convergent p[k]/q[k] within the bound
+    k := 2
+    for k+1 < len(q) && q[k+1].Cmp(maxDen) <= 0 {
+        k++
+    }
+    if k+3 >= len(q) {
+        return nil
+    }
+    convergent := new(big.Rat).SetFrac(p[k], q[k])
+
+    // The largest semiconvergent within the bound:
+    // (p[k-1] + m p[k]) / (q[k-1] + m q[k])
+    m := new(big.Int).Sub(maxDen, q[k-1])
+    m.Quo(m, q[k])
+    if m.Sign() == 0 {
+        return convergent
+    }
+    semi := new(big.Rat).SetFrac(
+        new(big.Int).Add(p[k-1], new(big.Int).Mul(m, p[k])),
+        new(big.Int).Add(q[k-1], new(big.Int).Mul(m, q[k])))
+
+    // Compare both with the closest value cf offers, orders of magnitude
+    // closer to the number than either candidate
+    value := new(big.Rat).SetFrac(p[len(p)-1], q[len(q)-1])
+    if ratDistance(semi, value).Cmp(ratDistance(convergent, value)) < 0 {
+        return semi
+    }
+    return convergent
+}
+
+func ratDistance(a, b *big.Rat) *big.Rat {
+    d := new(big.Rat).Sub(a, b)
+    return d.Abs(d)
+}
+
+// Return the convergents of pi with denominators of at most maxDen and the
+// best rational approximation of pi with such a denominator
+func Rational(ctx context.Context, maxDen *big.Int, opts *Options) (
+    convergents []*big.Rat, best *big.Rat, err error) {
+    if maxDen.Sign() <= 0 {
+        return nil, nil, errorf(ErrInvalidPrecision,
+            "pi: invalid maximum denominator %s", maxDen)
+    }
+
+    // The denominators grow by about a factor of 10 per term
+    terms := int(float64(maxDen.BitLen())*math.Log10(2)) + 5
+    for {
+        cf, _, err := ContinuedFraction(ctx, terms, opts)
+        if err != nil {
+            return nil, nil, err
+        }
+        best = BestApproximation(cf, maxDen)
+        if best == nil {
+            terms *= 2
+            continue
+        }
+
+        for _, c := range Convergents(cf) {
+            if c.Denom().Cmp(maxDen) > 0 {
+                break
+            }
+            convergents = append(convergents, c)
+        }
+        return convergents, best, nil
+    }
+}
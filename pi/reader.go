@@ -0,0 +1,62 @@
+// Streaming access to the decimal expansion of pi.
+
+package pi
+
+import (
+    "io"
+)
+
+type reader struct {
+    places int
+    stream *radixStream
+    chunk  []byte // unread part of the current chunk
+    point  bool   // decimal point still to be written
+    lead   bool   // the leading 3 has been written
+}
+
+// Return a reader producing pi with the given number of decimal places,
+// e.g. "3.14159" for 5 places, without a trailing newline. The value is
+// computed on the first call to Read; the decimal representation is produced
+// piece by piece while reading and never held in memory as a whole.
+func NewReader(places int) io.Reader {
+    if places < 0 {
+        places = 0
+    }
+    return &reader{places: places, point: places > 0}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+    if r.stream == nil {
+        r.stream = newRadixStream(Fixed(r.places), 10)
+    }
+
+    n := 0
+    for n < len(p) {
+        if r.point && r.lead {
+            p[n] = '.'
+            n++
+            r.point = false
+            continue
+        }
+
+        if len(r.chunk) == 0 {
+            r.chunk = r.stream.next()
+            if r.chunk == nil {
+                if n == 0 {
+                    return 0, io.EOF
+                }
+                break
+            }
+        }
+
+        m := len(r.chunk)
+        if !r.lead {
+            m = 1
+        }
+        m = copy(p[n:], r.chunk[:m])
+        r.chunk = r.chunk[m:]
+        n += m
+        r.lead = true
+    }
+    return n, nil
+}
@@ -0,0 +1,101 @@
+// Division by Newton's reciprocal.
+//
+// The reciprocal 1/d of a huge divisor follows from Newton's iteration
+//
+//    y = y + y (1 - d y)
+//
+// which doubles the number of correct bits with every step, so that every
+// step is computed at twice the precision of the one before it, from the
+// leading bits of d only. All steps together cost about as much as one
+// division by math/big, which divides recursively, but every quotient by d
+// costs a single multiplication after that: for many divisions by the same
+// d, like the unity of a fixed point computation. The quotients are
+// rounded instead of truncated, off by less than a unit either way.
+
+package pi
+
+import (
+    "math/big"
+)
+
+const (
+    // Quotients of fewer bits are left to math/big
+    reciprocalMinBits = 1 << 14
+
+    // Reciprocals of this many bits and less are divided out directly
+    reciprocalBaseBits = 1 << 12
+
+    // Bits carried beyond the precision asked for
+    reciprocalGuardBits = 64
+)
+
+// A divisor with its reciprocal, for quotients of up to a given size
+type reciprocal struct {
+    d    *big.Int
+    bits uint     // the quotients have at most this many bits
+    inv  *big.Int // about 2**(n + p) / d for d of n bits
+    n, p uint
+}
+
+// Return the reciprocal of d > 0 for quotients of up to the given number
+// of bits
+func newReciprocal(d *big.Int, bits int) *reciprocal {
+    r := &reciprocal{d: d, bits: uint(max(bits, 1))}
+    if r.bits < reciprocalMinBits {
+        return r
+    }
+    r.p = r.bits + reciprocalGuardBits
+    r.inv = approxReciprocal(d, r.p)
+    r.n = uint(d.BitLen())
+    return r
+}
+
+// Return x / d for 0 <= x < d * 2**bits, rounded, off by little more than
+// half a unit
+func (r *reciprocal) quo(x *big.Int) *big.Int {
+    if r.inv == nil {
+        return new(big.Int).Quo(x, r.d)
+    }
+    // x / d = (x / 2**s) * inv / 2**(n + p - s), with the leading bits
+    // of x only
+    s := max(x.BitLen()-int(r.p+reciprocalGuardBits), 0)
+    q := new(big.Int).Rsh(x, uint(s))
+    q.Mul(q, r.inv)
+    shift := int(r.n+r.p) - s
+    if shift <= 0 {
+        return q.Lsh(q, uint(-shift))
+    }
+    // Rounded, the errors of inv and of the bits of x left out are tiny
+    q.Add(q, new(big.Int).Lsh(big.NewInt(1), uint(shift-1)))
+    return q.Rsh(q, uint(shift))
+}
+
+// Return 2**(n + p) / d for d > 0 of n bits, off by a few units, from the
+// leading p bits of d and a few more
+func approxReciprocal(d *big.Int, p uint) *big.Int {
+    n := uint(d.BitLen())
+    if keep := p + reciprocalGuardBits; n > keep {
+        // 2**(n + p) / d = 2**(keep + p) / (d / 2**(n - keep))
+        d = new(big.Int).Rsh(d, n-keep)
+        n = keep
+    }
+    if p <= reciprocalBaseBits {
+        y := new(big.Int).Lsh(big.NewInt(1), n+p)
+        return y.Quo(y, d)
+    }
+
+    // y0 = 2**(n + h) / d with about half the bits, e = 2**(n + h) - d y0
+    h := p/2 + reciprocalGuardBits
+    y0 := approxReciprocal(d, h)
+    e := new(big.Int).Lsh(big.NewInt(1), n+h)
+    e.Sub(e, new(big.Int).Mul(d, y0))
+
+    // y = y0 2**(p - h) + y0 e / 2**(n + 2h - p), of which y0 e needs the
+    // leading h bits only
+    shift := n + 2*h - p
+    drop := uint(max(int(n+h)-int(p+reciprocalGuardBits), 0))
+    e.Rsh(e, drop)
+    e.Mul(e, y0)
+    e.Rsh(e, shift-drop)
+    return e.Add(e, y0.Lsh(y0, p-h))
+}
@@ -0,0 +1,70 @@
+package pi
+
+import (
+    "context"
+    "math/big"
+    "math/rand"
+    "testing"
+)
+
+// Return a random number of exactly the given number of bits
+func randomBits(rng *rand.Rand, bits int) *big.Int {
+    x := new(big.Int).Rand(rng, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+    return x.SetBit(x, bits-1, 1)
+}
+
+func TestReciprocalQuo(t *testing.T) {
+    rng := rand.New(rand.NewSource(1))
+    one := big.NewInt(1)
+    for _, dbits := range []int{1, 64, 5000, 70000} {
+        for _, qbits := range []int{100, 20000, 70000, 300000} {
+            d := randomBits(rng, dbits)
+            r := newReciprocal(d, qbits)
+            limit := new(big.Int).Lsh(d, uint(qbits))
+            for _, x := range []*big.Int{
+                new(big.Int).Rand(rng, limit),
+                new(big.Int).Sub(limit, one),
+                new(big.Int).Lsh(d, uint(qbits/2)),
+            } {
+                want, rest := new(big.Int).QuoRem(x, d, new(big.Int))
+                got := r.quo(x)
+                // Rounded: the truncated quotient or one more, and an
+                // exact quotient exactly
+                diff := new(big.Int).Sub(got, want)
+                if diff.Sign() < 0 || diff.Cmp(one) > 0 ||
+                    rest.Sign() == 0 && diff.Sign() != 0 {
+                    t.Fatalf("%d by %d bits: off by %v", x.BitLen(), dbits,
+                        diff)
+                }
+            }
+        }
+    }
+}
+
+func TestSqrtNewton(t *testing.T) {
+    rng := rand.New(rand.NewSource(2))
+    ctx := context.Background()
+    for _, bits := range []int{sqrtHalvingBits - 1, sqrtHalvingBits,
+        20001, 70000, 300000} {
+        x := randomBits(rng, bits)
+        root := new(big.Int).Rsh(x, uint(bits/2))
+        square := new(big.Int).Mul(root, root)
+        for _, n := range []*big.Int{
+            x,
+            square,
+            new(big.Int).Sub(square, big.NewInt(1)),
+            new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(bits)),
+                big.NewInt(1)),
+        } {
+            got, err := sqrtNewton(ctx, n, nil)
+            if err != nil {
+                t.Fatal(err)
+            }
+            want, _ := sqrtHalving(ctx, n, nil)
+            if got.Cmp(want) != 0 {
+                t.Fatalf("root of %d bits: off by %v", n.BitLen(),
+                    new(big.Int).Sub(got, want))
+            }
+        }
+    }
+}
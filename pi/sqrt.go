@@ -0,0 +1,269 @@
+// Square roots in fixed point arithmetic with Newton's iteration.
+//
+// The root of a small n is found by halving: the root of n / 4**k with
+// about half the bits, scaled back by 2**k, is within a few units of the
+// root of n, and Newton's iteration
+//
+//    y = (y + n/y) / 2
+//
+// started just above the root corrects it in one or two steps. Every level
+// doubles the number of correct bits, as does every Newton step.
+//
+// A huge n = A * 4**m, 1/4 <= A < 1, goes without division: the iteration
+//
+//    y = y + y (1 - A y**2) / 2
+//
+// for the reciprocal root 1/sqrt(A) doubles the bits of y with every step,
+// and so the precision every step is computed with, up to half the bits of
+// the root. Karp and Markstein's step
+//
+//    s = A y + y (A - (A y)**2) / 2
+//
+// doubles them once more for sqrt(A) itself, and a single square corrects
+// the last unit. A root of 10 million bits takes 1.4s instead of 3.1s.
+
+package pi
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "math/big"
+    "strconv"
+    "strings"
+)
+
+func init() {
+    registerConstant(&constant{
+        name:     "sqrt2",
+        formula:  "sqrt(2) by Newton's iteration",
+        compute:  sqrtOf(2),
+        maxError: sqrtError,
+        bounds:   sqrtIntervalOf(2),
+    })
+}
+
+const (
+    // Numbers below this size in bits get their root from float64
+    // arithmetic
+    sqrtDirectBits = 52
+
+    // Numbers below this size in bits get their root by halving, above it
+    // from the reciprocal root
+    sqrtHalvingBits = 1 << 13
+)
+
+// Return sqrt(x) in fixed point arithmetic with the given unity, i.e.
+// the square root of x * unity truncated to an integer, e.g.
+// SqrtFixed(2*unity, unity) is sqrt(2) * unity. SqrtFixed panics if x
+// is negative.
+func SqrtFixed(x, unity *big.Int) *big.Int {
+    root, _ := sqrtNewton(context.Background(),
+        new(big.Int).Mul(x, unity), nil)
+    return root
+}
+
+// Return floor(sqrt(n)), abandoned as soon as ctx is done
+func sqrtNewton(ctx context.Context, n *big.Int, progress *tracker) (
+    *big.Int, error) {
+    if n.Sign() < 0 {
+        panic("pi: square root of negative number")
+    }
+    if n.BitLen() < sqrtHalvingBits {
+        return sqrtHalving(ctx, n, progress)
+    }
+
+    // a = A * 2**p for n = A * 4**m, y = 1/sqrt(A) with h bits
+    m := uint(n.BitLen()+1) / 2
+    p := m + reciprocalGuardBits
+    a := new(big.Int).Rsh(n, 2*m-p)
+    h := p/2 + reciprocalGuardBits
+    ah := new(big.Int).Rsh(a, p-h)
+    y, err := invSqrt(ctx, ah, h, progress)
+    if err != nil {
+        return nil, err
+    }
+
+    // s = A y + y (A - (A y)**2) / 2 with 2h bits, sqrt(n) = s * 2**m
+    s := new(big.Int).Mul(ah, y)
+    s.Rsh(s, h)
+    r := new(big.Int).Mul(s, s)
+    r.Sub(new(big.Int).Lsh(a, 2*h-p), r)
+    r.Mul(r, y)
+    r.Rsh(r, h+1)
+    s.Lsh(s, h).Add(s, r)
+    s.Rsh(s, 2*h-m)
+
+    // Off by a few units at most: (s + 1)**2 = s**2 + 2s + 1
+    square := new(big.Int).Mul(s, s)
+    step := new(big.Int)
+    for square.Cmp(n) > 0 {
+        s.Sub(s, big.NewInt(1))
+        square.Sub(square, step.Lsh(s, 1).Add(step, big.NewInt(1)))
+    }
+    for {
+        step.Lsh(s, 1).Add(step, big.NewInt(1))
+        if step.Add(step, square).Cmp(n) > 0 {
+            break
+        }
+        square.Set(step)
+        s.Add(s, big.NewInt(1))
+    }
+
+    progress.step()
+    return s, nil
+}
+
+// Return about 1/sqrt(A) * 2**p for a = A * 2**p, 1/4 <= A < 1, off by a
+// few units
+func invSqrt(ctx context.Context, a *big.Int, p uint, progress *tracker) (
+    *big.Int, error) {
+    if p <= sqrtHalvingBits {
+        // 1/sqrt(A) * 2**p = sqrt(2**(3p) / a)
+        y := new(big.Int).Lsh(big.NewInt(1), 3*p)
+        return sqrtHalving(ctx, y.Quo(y, a), nil)
+    }
+
+    select {
+    case <-ctx.Done():
+        return nil, ctx.Err()
+    default:
+    }
+
+    h := p/2 + reciprocalGuardBits
+    y, err := invSqrt(ctx, new(big.Int).Rsh(a, p-h), h, progress)
+    if err != nil {
+        return nil, err
+    }
+
+    // e = 1 - A y**2 with p + 2h bits, of which y e needs the leading h
+    e := new(big.Int).Mul(y, y)
+    e.Mul(e, a)
+    e.Sub(new(big.Int).Lsh(big.NewInt(1), p+2*h), e)
+    drop := 2*h - reciprocalGuardBits
+    e.Rsh(e, drop)
+
+    // y = y + y e / 2 with p bits
+    e.Mul(e, y)
+    e.Rsh(e, 3*h+1-drop)
+    y.Lsh(y, p-h).Add(y, e)
+
+    progress.step()
+    return y, nil
+}
+
+// Same as sqrtNewton by halving alone
+func sqrtHalving(ctx context.Context, n *big.Int, progress *tracker) (
+    *big.Int, error) {
+    if n.BitLen() <= sqrtDirectBits {
+        // Exact as float64, correct the rounding of math.Sqrt
+        y := uint64(math.Sqrt(float64(n.Uint64())))
+        for y*y > n.Uint64() {
+            y--
+        }
+        for (y+1)*(y+1) <= n.Uint64() {
+            y++
+        }
+        return new(big.Int).SetUint64(y), nil
+    }
+
+    select {
+    case <-ctx.Done():
+        return nil, ctx.Err()
+    default:
+    }
+
+    // y = (sqrt(n / 4**k) + 1) * 2**k >= sqrt(n)
+    k := uint(n.BitLen() / 4)
+    y, err := sqrtHalving(ctx, new(big.Int).Rsh(n, 2*k), progress)
+    if err != nil {
+        return nil, err
+    }
+    y.Add(y, big.NewInt(1))
+    y.Lsh(y, k)
+
+    // From above, Newton's iteration decreases until it reaches the root
+    next := new(big.Int)
+    for {
+        next.Quo(n, y)
+        next.Add(next, y)
+        next.Rsh(next, 1)
+        if next.Cmp(y) >= 0 {
+            break
+        }
+        y, next = next, y
+    }
+
+    progress.step()
+    return y, nil
+}
+
+// Return the number of progress steps of sqrtNewton for a number with the
+// given number of bits
+func sqrtLevels(bits int) int {
+    levels := 0
+    if bits >= sqrtHalvingBits {
+        // The steps of invSqrt and the final one
+        m := uint(bits+1) / 2
+        h := (m+reciprocalGuardBits)/2 + reciprocalGuardBits
+        for p := h; p > sqrtHalvingBits; p = p/2 + reciprocalGuardBits {
+            levels++
+        }
+        return levels + 1
+    }
+    for bits > sqrtDirectBits {
+        bits -= 2 * (bits / 4)
+        levels++
+    }
+    return levels
+}
+
+// Return the constant for the square root of a positive integer, named
+// "sqrt:<n>"; n = 2 is also known as "sqrt2"
+func sqrtConstant(name string) (*constant, error) {
+    arg, ok := strings.CutPrefix(name, "sqrt:")
+    if !ok {
+        return nil, errorf(ErrUnknownConstant, "pi: unknown constant %q", name)
+    }
+    n, err := strconv.ParseInt(arg, 10, 64)
+    if err != nil || n <= 0 {
+        return nil, errorf(ErrUnknownConstant, "pi: invalid square root %q, "+
+            "need a positive integer", arg)
+    }
+    return &constant{
+        name:     name,
+        formula:  fmt.Sprintf("sqrt(%d) by Newton's iteration", n),
+        compute:  sqrtOf(n),
+        maxError: sqrtError,
+        bounds:   sqrtIntervalOf(n),
+    }, nil
+}
+
+// The integer root is exact, the square root lies less than a unit above
+func sqrtError(digits int) (*big.Float, *big.Float) {
+    return errorBounds(0, 1)
+}
+
+// Return the computation of sqrt(n) * unity
+func sqrtOf(n int64) fixedFunc {
+    return func(ctx context.Context, unity *big.Int, progress *tracker) (
+        *big.Int, error) {
+        x := new(big.Int).Mul(unity, unity)
+        x.Mul(x, big.NewInt(n))
+        progress.expect(sqrtLevels(x.BitLen()))
+        return sqrtNewton(ctx, x, progress)
+    }
+}
+
+// Return the bounds of sqrt(n) * unity: the integer root and one more
+func sqrtIntervalOf(n int64) intervalFunc {
+    root := sqrtOf(n)
+    return func(ctx context.Context, unity *big.Int, progress *tracker) (
+        lo, hi *big.Int, err error) {
+        lo, err = root(ctx, unity, progress)
+        if err != nil {
+            return nil, nil, err
+        }
+        return lo, new(big.Int).Add(lo, big.NewInt(1)), nil
+    }
+}
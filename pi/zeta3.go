@@ -0,0 +1,87 @@
+// Apery's constant zeta(3) with the series of Amdeberhan and Zeilberger.
+//
+//              1               k                          (k!)**10
+//    zeta(3) = -- sum  (-1)  (205k**2 + 250k + 77) -------------
+//              64 k=0                               ((2k+1)!)**5
+//
+// The factorial part c(k) = (k!)**10 / ((2k+1)!)**5 follows from c(k-1) by
+//
+//    c(k) = c(k-1) * k**10 / (2k(2k+1))**5
+//
+// and falls by about a factor of 1024, three digits, per term.
+
+package pi
+
+import (
+    "context"
+    "math"
+    "math/big"
+)
+
+func init() {
+    registerConstant(&constant{
+        name:     "zeta3",
+        formula:  "zeta(3) = 1/64 * sum((-1)^k (205k^2 + 250k + 77) " +
+            "(k!)^10 / ((2k+1)!)^5)",
+        compute:  zeta3,
+        maxError: zeta3Error,
+    })
+}
+
+// Estimate the number of terms of zeta3 for the given number of digits
+func zeta3Terms(digits int) int {
+    return int(math.Ceil(float64(digits) / math.Log10(1024)))
+}
+
+// Bound the error of zeta3: c(k) is off by less than a unit and a bit,
+// which the polynomial factor of the terms multiplies. The sum of the
+// factors up to t terms is about 70 t**3, divided by 64.
+func zeta3Error(digits int) (*big.Float, *big.Float) {
+    t := float64(zeta3Terms(digits) + 1)
+    e := t*t*t + 2*t*t + 2*t + 2
+    return errorBounds(e, e)
+}
+
+// Compute zeta(3) times unity with the Amdeberhan-Zeilberger series
+func zeta3(ctx context.Context, unity *big.Int, progress *tracker) (
+    *big.Int, error) {
+    progress.expect(zeta3Terms(unityDigits(unity)))
+
+    // c = c(0) * unity, sum = 77 * c(0)
+    c := new(big.Int).Set(unity)
+    sum := new(big.Int).Mul(c, big.NewInt(77))
+    num := new(big.Int)
+    den := new(big.Int)
+    term := new(big.Int)
+
+    for k := int64(1); ; k++ {
+        select {
+        case <-ctx.Done():
+            return nil, ctx.Err()
+        default:
+        }
+
+        // c = c * k**10 / (2k(2k+1))**5
+        kk := big.NewInt(k * k)
+        num.Exp(kk, big.NewInt(5), nil)
+        den.Exp(big.NewInt(2*k*(2*k+1)), big.NewInt(5), nil)
+        c.Mul(c, num)
+        c.Quo(c, den)
+
+        // term = c (205k**2 + 250k + 77)
+        term.Mul(c, big.NewInt(205*k*k+250*k+77))
+        if term.Sign() == 0 {
+            break
+        }
+        if k%2 == 1 {
+            sum.Sub(sum, term)
+        } else {
+            sum.Add(sum, term)
+        }
+
+        progress.step()
+    }
+
+    // zeta(3) = sum / 64
+    return sum.Rsh(sum, 6), nil
+}
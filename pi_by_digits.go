@@ -1,137 +1,169 @@
 // Computing pi with arbitrary precision using Machin's formula.
-// Algorithm is taken from:
-// http://en.literateprograms.org/Pi_with_Machin%27s_formula_%28Python%29
+// The computation itself lives in package pi, this is the command line
+// interface to it. Every task has its own subcommand with its own flags,
+// computing the digits is the default.
 
 package main
 
 import (
+    "errors"
+    "flag"
     "fmt"
-    "math/big"
+    "io"
+    "log/slog"
     "os"
     "path/filepath"
-    "strconv"
+
+    "github.com/miromotl/pi_by_digits/pi"
 )
 
-func main() {
-    places := handleCommandLine(1000)  // 1000 digits is the default
-    scaledPi := fmt.Sprint(π(places))
-    fmt.Printf("3.%s\n", scaledPi[1:])
+type command struct {
+    name  string
+    args  string // synopsis of the arguments following the flags
+    short string // one line description for the command overview
+
+    // Define the flags on fs and return the function running the command
+    // with the arguments left after parsing the flags
+    setup func(fs *flag.FlagSet) func(args []string) error
+}
+
+// The subcommands, the first one is the default
+func commands() []*command {
+    return []*command{
+        computeCommand,
+        verifyCommand,
+        diffCommand,
+        statsCommand,
+        searchCommand,
+        findCommand,
+        indexCommand,
+        queryCommand,
+        extractCommand,
+        cfCommand,
+        rationalCommand,
+        evalCommand,
+        serveCommand,
+        benchCommand,
+        replCommand,
+        vizCommand,
+    }
 }
 
-func handleCommandLine(defaultValue int) int {
-    if len(os.Args) > 1 {
-        if os.Args[1] == "-h" || os.Args[1] == "--help" {
-            // handle call for help
-            usage := "usage: %s [digits]\n e.g.: %s 10000"
-            app := filepath.Base(os.Args[0])
-            fmt.Fprintln(os.Stderr, fmt.Sprintf(usage, app, app))
+func main() {
+    app := filepath.Base(os.Args[0])
+    args := os.Args[1:]
+    cmd := commands()[0]
+    // Until the flags of the command choose
+    slog.SetDefault(slog.New(newMessageHandler(os.Stderr, slog.LevelInfo)))
+
+    if len(args) > 0 {
+        switch args[0] {
+        case "-h", "-help", "--help":
+            usage(os.Stderr, app)
             os.Exit(1)
+        case "help":
+            // help [command]
+            if len(args) > 1 {
+                if c := lookupCommand(args[1]); c != nil {
+                    fs, _ := c.flagSet(app)
+                    fs.Usage()
+                    os.Exit(1)
+                }
+            }
+            usage(os.Stderr, app)
+            os.Exit(1)
+        }
+
+        if c := lookupCommand(args[0]); c != nil {
+            cmd, args = c, args[1:]
         }
-        
-        if x, err := strconv.Atoi(os.Args[1]); err != nil {
-            fmt.Fprintf(os.Stderr, "ignoring invalid number of " +
-                "digits: will display %d\n", defaultValue)
-        } else {
-            return x
+    }
+
+    fs, run := cmd.flagSet(app)
+    fs.Parse(args)
+    err := cmd.configure(fs)
+    if err == nil {
+        err = run(fs.Args())
+    }
+    if err != nil {
+        slog.Error(fmt.Sprintf("%s %s: %v", app, cmd.name, err),
+            "command", cmd.name, "error", err)
+        if invalidArguments(err) {
+            slog.Info(fmt.Sprintf("run \"%s help %s\" for usage", app,
+                cmd.name))
+            os.Exit(2)
         }
+        os.Exit(1)
     }
-    
-    return defaultValue
 }
 
-func π(places int) *big.Int {
-    digits := big.NewInt(int64(places))
-    unity := big.NewInt(0)
-    ten := big.NewInt(10)
-    exponent := big.NewInt(0)
-    
-    // Compute the unity scaling factor, add extra 10 digits 
-    // to avoid rounding errors
-    // unity = 10**(digits + 10)
-    unity.Exp(ten, exponent.Add(digits, ten), nil)
-    
-    // Start approximation of pi with 4
-    pi := big.NewInt(4)
-    
-    // Machin's formula
-    // pi = 4 * (4 * arccot(5) - arccot(239))
-    
-    // Left part of Machin's formula
-    left := arccot(big.NewInt(5), unity)
-    left.Mul(left, big.NewInt(4))
-    
-    // Right part of Machin's formula
-    right := arccot(big.NewInt(239), unity)
-    
-    // Subtract right from left and save result in left
-    left.Sub(left, right)
-    
-    // Bring it all together to compute pi: pi = 4 * left
-    pi.Mul(pi, left)
-    
-    // Remove the extra 10 digits
-    // pi = pi / 10**10
-    pi.Div(pi, big.NewInt(0).Exp(ten, ten, nil))
-    
-    return pi
+// Invalid arguments, reported with exit status 2 like invalid flags
+type usageError string
+
+func (e usageError) Error() string {
+    return string(e)
 }
 
-// Compute arccot with a given precision
-//
-//             1     1     1     1
-// arccot(x) = -  - --- + --- - --- + ...
-//              1     3     5     7
-//             x    3x    5x    7x
-//
-// To calculate arccot of an argument x, we start by dividing the number 1 
-// (represented by 10n, which we provide as the argument unity) by x to obtain
-// the first term. We then repeatedly divide by x**2 and a counter value that 
-// runs over 3, 5, 7, ..., to obtain each next term. The summation is stopped 
-// at the first zero term, which in this fixed-point representation corresponds 
-// to a real value less than 10-n.
-
-func arccot(x, unity *big.Int) *big.Int {
-    // Init sum with 1/x
-    sum := big.NewInt(0)
-    sum.Div(unity, x)
-    
-    // Init xpower with 1/x
-    xpower := big.NewInt(0)
-    xpower.Div(unity, x)
-    
-    // Init n with 3, sign with -1, zero with 0 and square with x*x
-    n := big.NewInt(3)
-    sign := big.NewInt(-1)
-    zero := big.NewInt(0)
-    square := big.NewInt(0)
-    square.Mul(x, x)
-    
-    // Compute successive terms until first term is 0
-    for {
-        // xpower = xpower / x*x
-        xpower.Div(xpower, square)
-        
-        //         1
-        // term = ---
-        //          n
-        //        nx
-        term := big.NewInt(0)
-        term.Div(xpower, n)
-        
-        if term.Cmp(zero) == 0 { // term == 0
-            break
+func usagef(format string, a ...interface{}) error {
+    return usageError(fmt.Sprintf(format, a...))
+}
+
+// Report whether err is due to the arguments, a usage error or an invalid
+// request to package pi
+func invalidArguments(err error) bool {
+    var uerr usageError
+    for _, kind := range []error{pi.ErrInvalidPrecision, pi.ErrInvalidBase,
+        pi.ErrUnknownAlgorithm, pi.ErrUnknownConstant,
+        pi.ErrInvalidExpression, pi.ErrUnsupported} {
+        if errors.Is(err, kind) {
+            return true
+        }
+    }
+    return errors.As(err, &uerr)
+}
+
+func lookupCommand(name string) *command {
+    for _, c := range commands() {
+        if c.name == name {
+            return c
         }
-        
-        // sum = sum + sign*term
-        addend := big.NewInt(0)
-        sum.Add(sum, addend.Mul(sign, term))
-        
-        // Prepare for next iteration
-        // sign = -sign
-        // n = n + 2
-        sign.Neg(sign)
-        n.Add(n, big.NewInt(2))
     }
-    
-    return sum
-}
\ No newline at end of file
+    return nil
+}
+
+// Return the flag set of the command and the function running it
+func (cmd *command) flagSet(app string) (*flag.FlagSet,
+    func(args []string) error) {
+    fs := flag.NewFlagSet(app+" "+cmd.name, flag.ExitOnError)
+    fs.Usage = func() {
+        fmt.Fprintf(fs.Output(), "usage: %s %s [flags] %s\n%s\n\nflags:\n",
+            app, cmd.name, cmd.args, cmd.short)
+        fs.PrintDefaults()
+    }
+    run := cmd.setup(fs)
+    logs := defineLogFlags(fs)
+    j := defineParallelism(fs)
+    return fs, func(args []string) error {
+        if err := logs.setup(); err != nil {
+            return err
+        }
+        if err := setParallelism(*j); err != nil {
+            return err
+        }
+        return run(args)
+    }
+}
+
+// Print the overview of all commands
+func usage(w io.Writer, app string) {
+    fmt.Fprintf(w, "usage: %s [command] [flags] [arguments]\n\n"+
+        "commands:\n", app)
+    for _, c := range commands() {
+        fmt.Fprintf(w, "  %-10s %s\n", c.name, c.short)
+    }
+    fmt.Fprintf(w, "\nwithout a command: %s %s\n", app, commands()[0].name)
+    fmt.Fprintf(w, "run \"%s help <command>\" for the flags of a "+
+        "command\n", app)
+    fmt.Fprintf(w, "\nexit status: 0 on success, 1 for failures, 2 for "+
+        "invalid arguments\n")
+}
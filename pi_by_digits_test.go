@@ -0,0 +1,41 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+// Run the command named by the first argument with the others, as main
+// does but without the configuration
+func runCommand(t *testing.T, args ...string) error {
+    t.Helper()
+    cmd := lookupCommand(args[0])
+    if cmd == nil {
+        t.Fatalf("no command %s", args[0])
+    }
+    fs, run := cmd.flagSet("pi_by_digits")
+    if err := fs.Parse(args[1:]); err != nil {
+        return err
+    }
+    return run(fs.Args())
+}
+
+// Run the command as runCommand does and return what it writes to stdout
+func runOutput(t *testing.T, args ...string) (string, error) {
+    t.Helper()
+    f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+    stdout := os.Stdout
+    os.Stdout = f
+    err = runCommand(t, args...)
+    os.Stdout = stdout
+    out, rerr := os.ReadFile(f.Name())
+    if rerr != nil {
+        t.Fatal(rerr)
+    }
+    return string(out), err
+}
@@ -0,0 +1,108 @@
+// Profiling hooks: CPU and heap profiles and execution traces of a run,
+// to be read with go tool pprof and go tool trace.
+
+package main
+
+import (
+    "flag"
+    "net/http"
+    "net/http/pprof"
+    "os"
+    "runtime"
+    rpprof "runtime/pprof"
+    "runtime/trace"
+)
+
+// The profiling flags of a command
+type profileFlags struct {
+    cpu   string
+    mem   string
+    trace string
+}
+
+func (p *profileFlags) define(fs *flag.FlagSet) {
+    fs.StringVar(&p.cpu, "cpuprofile", "",
+        "write a CPU profile of the run to this file")
+    fs.StringVar(&p.mem, "memprofile", "",
+        "write a heap profile at the end of the run to this file")
+    fs.StringVar(&p.trace, "trace", "",
+        "write an execution trace of the run to this file")
+}
+
+// Start the requested profiles, the returned function stops them and
+// writes the heap profile
+func (p *profileFlags) start() (stop func() error, err error) {
+    var files []*os.File
+    closeAll := func() error {
+        var first error
+        for _, f := range files {
+            if err := f.Close(); err != nil && first == nil {
+                first = err
+            }
+        }
+        return first
+    }
+    create := func(name string) (*os.File, error) {
+        f, err := os.Create(name)
+        if err != nil {
+            closeAll()
+            return nil, err
+        }
+        files = append(files, f)
+        return f, nil
+    }
+
+    if p.cpu != "" {
+        f, err := create(p.cpu)
+        if err != nil {
+            return nil, err
+        }
+        if err := rpprof.StartCPUProfile(f); err != nil {
+            closeAll()
+            return nil, err
+        }
+    }
+    if p.trace != "" {
+        f, err := create(p.trace)
+        if err != nil {
+            rpprof.StopCPUProfile()
+            return nil, err
+        }
+        if err := trace.Start(f); err != nil {
+            rpprof.StopCPUProfile()
+            closeAll()
+            return nil, err
+        }
+    }
+
+    return func() error {
+        if p.cpu != "" {
+            rpprof.StopCPUProfile()
+        }
+        if p.trace != "" {
+            trace.Stop()
+        }
+        if p.mem != "" {
+            f, err := create(p.mem)
+            if err != nil {
+                return err
+            }
+            // Up to date statistics of the objects still alive
+            runtime.GC()
+            if err := rpprof.WriteHeapProfile(f); err != nil {
+                closeAll()
+                return err
+            }
+        }
+        return closeAll()
+    }, nil
+}
+
+// Serve the profiles of the running process under /debug/pprof/
+func handlePprof(mux *http.ServeMux) {
+    mux.HandleFunc("/debug/pprof/", pprof.Index)
+    mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+    mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+    mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+    mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
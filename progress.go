@@ -0,0 +1,51 @@
+// Periodic progress reports on stderr.
+
+package main
+
+import (
+    "fmt"
+    "log/slog"
+    "time"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+type progressPrinter struct {
+    places   int // of the computation in progress
+    interval time.Duration
+    start    time.Time
+    last     time.Time
+}
+
+func newProgressPrinter(interval time.Duration) *progressPrinter {
+    now := time.Now()
+    return &progressPrinter{-1, interval, now, now}
+}
+
+// Print the progress at most once per interval
+func (p *progressPrinter) update(progress pi.Progress) {
+    now := time.Now()
+    if progress.Places != p.places {
+        // A new computation, e.g. the next one of a time budget run
+        p.places, p.start = progress.Places, now
+    }
+    if now.Sub(p.last) < p.interval {
+        return
+    }
+    p.last = now
+
+    elapsed := now.Sub(p.start)
+    fraction := progress.Fraction()
+    rate := fraction * float64(progress.Places) / elapsed.Seconds()
+
+    eta := "unknown"
+    if fraction > 0 {
+        remaining := time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+        eta = remaining.Round(time.Second).String()
+    }
+
+    slog.Info(fmt.Sprintf("%d/%d terms, %.1f%% done, %.0f digits/s, ETA %s",
+        progress.Terms, progress.ExpectedTerms, 100*fraction, rate, eta),
+        "terms", progress.Terms, "expected_terms", progress.ExpectedTerms,
+        "digits_per_second", rate, "eta", eta)
+}
@@ -0,0 +1,87 @@
+// Per-client rate limiting of the serve command with token buckets.
+
+package main
+
+import (
+    "net"
+    "net/http"
+    "sync"
+    "time"
+)
+
+// Clients without a request for this long are forgotten
+const limiterIdle = 10 * time.Minute
+
+// Allows every client rate requests per second on average and bursts of
+// up to burst requests
+type rateLimiter struct {
+    rate  float64
+    burst float64
+
+    mu      sync.Mutex
+    clients map[string]*tokenBucket
+    swept   time.Time
+}
+
+type tokenBucket struct {
+    tokens float64
+    last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+    return &rateLimiter{
+        rate:    rate,
+        burst:   float64(max(burst, 1)),
+        clients: map[string]*tokenBucket{},
+        swept:   time.Now(),
+    }
+}
+
+// Take a token of the client, report whether there was one, and if not,
+// how long until there is
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+    now := time.Now()
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.sweep(now)
+
+    b, ok := l.clients[client]
+    if !ok {
+        b = &tokenBucket{tokens: l.burst, last: now}
+        l.clients[client] = b
+    }
+    b.tokens += now.Sub(b.last).Seconds() * l.rate
+    if b.tokens > l.burst {
+        b.tokens = l.burst
+    }
+    b.last = now
+
+    if b.tokens < 1 {
+        wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+        return false, wait
+    }
+    b.tokens--
+    return true, 0
+}
+
+// Forget the idle clients, at most once per idle period
+func (l *rateLimiter) sweep(now time.Time) {
+    if now.Sub(l.swept) < limiterIdle {
+        return
+    }
+    for client, b := range l.clients {
+        if now.Sub(b.last) >= limiterIdle {
+            delete(l.clients, client)
+        }
+    }
+    l.swept = now
+}
+
+// Return the client of a request, its IP address without the port
+func clientOf(r *http.Request) string {
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        return r.RemoteAddr
+    }
+    return host
+}
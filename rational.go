@@ -0,0 +1,81 @@
+// The rational command: convergents and best rational approximations.
+
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "io"
+    "math/big"
+    "os"
+    "strings"
+    "text/tabwriter"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+var rationalCommand = &command{
+    name:  "rational",
+    args:  "",
+    short: "print the convergents and the best rational approximation of pi",
+    setup: setupRational,
+}
+
+func setupRational(fs *flag.FlagSet) func(args []string) error {
+    maxDen := fs.String("max-denominator", "1000000",
+        "largest denominator of the approximations")
+    algo := fs.String("algo", pi.DefaultAlgorithm,
+        "algorithm: "+strings.Join(pi.Algorithms(), ", ")+" or auto")
+
+    return func(args []string) error {
+        if len(args) > 0 {
+            return usagef("unexpected arguments")
+        }
+        bound, ok := new(big.Int).SetString(*maxDen, 10)
+        if !ok || bound.Sign() <= 0 {
+            return usagef("invalid maximum denominator %q", *maxDen)
+        }
+        if err := checkAlgorithm(*algo); err != nil {
+            return err
+        }
+
+        convergents, best, err := pi.Rational(context.Background(), bound,
+            &pi.Options{Algorithm: *algo})
+        if err != nil {
+            return err
+        }
+
+        // A reference value far more precise than any of the fractions
+        places := 2*len(bound.String()) + 20
+        x, err := pi.Compute(context.Background(), places,
+            &pi.Options{Algorithm: *algo})
+        if err != nil {
+            return err
+        }
+        value := new(big.Rat).SetFrac(x,
+            new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil))
+
+        w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+        fmt.Fprintf(w, "convergent\terror\n")
+        for _, c := range convergents {
+            printApproximation(w, c, value)
+        }
+        if err := w.Flush(); err != nil {
+            return err
+        }
+
+        fmt.Printf("\nbest with denominator <= %s: %s, error %+.3e\n", bound,
+            best.RatString(), approximationError(best, value))
+        return nil
+    }
+}
+
+func printApproximation(w io.Writer, r, value *big.Rat) {
+    fmt.Fprintf(w, "%s\t%+.3e\n", r.RatString(), approximationError(r, value))
+}
+
+func approximationError(r, value *big.Rat) float64 {
+    e, _ := new(big.Rat).Sub(r, value).Float64()
+    return e
+}
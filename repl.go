@@ -0,0 +1,162 @@
+// The repl command: explore the digits of pi interactively.
+//
+// The digits computed for one command are kept for the next ones, a
+// command needing no more of them than computed so far is answered right
+// away. Interrupting a computation returns to the prompt.
+
+package main
+
+import (
+    "bufio"
+    "context"
+    "flag"
+    "fmt"
+    "os"
+    "os/signal"
+    "strings"
+)
+
+var replCommand = &command{
+    name:  "repl",
+    args:  "",
+    short: "read commands like digits 5000, search 2718, stats interactively",
+    setup: setupRepl,
+}
+
+const replHelp = `commands:
+  digits N          print pi with N digits after the point
+  search PATTERN    print the places of a digit sequence
+  stats             print digit frequencies, longest runs and normality tests
+  help              print this help
+  quit              leave, as does end of input
+
+search and stats work on the digits computed so far, at least %d.
+`
+
+func setupRepl(fs *flag.FlagSet) func(args []string) error {
+    return func(args []string) error {
+        if len(args) > 0 {
+            return usagef("unexpected arguments")
+        }
+        r := &repl{out: bufio.NewWriter(os.Stdout)}
+        return r.run(os.Stdin)
+    }
+}
+
+// The state of the repl
+type repl struct {
+    out   *bufio.Writer
+    cache digitCache
+}
+
+// Read and run commands until end of input or quit
+func (r *repl) run(in *os.File) error {
+    // Prompt only people, not scripts
+    prompt := ""
+    info, err := in.Stat()
+    if err == nil && info.Mode()&os.ModeCharDevice != 0 {
+        prompt = "pi> "
+    }
+
+    s := bufio.NewScanner(in)
+    for {
+        fmt.Fprint(r.out, prompt)
+        if err := r.out.Flush(); err != nil {
+            return err
+        }
+        if !s.Scan() {
+            return s.Err()
+        }
+        words := strings.Fields(s.Text())
+        if len(words) == 0 {
+            continue
+        }
+        if words[0] == "quit" || words[0] == "exit" {
+            return nil
+        }
+
+        ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+        err := r.eval(ctx, words)
+        stop()
+        if err != nil {
+            fmt.Fprintf(r.out, "error: %v\n", err)
+        }
+    }
+}
+
+// Run one command
+func (r *repl) eval(ctx context.Context, words []string) error {
+    switch words[0] {
+    case "digits":
+        if len(words) != 2 {
+            return fmt.Errorf("usage: digits N")
+        }
+        places, err := parsePlaces(words[1])
+        if err != nil {
+            return err
+        }
+        digits, err := r.digits(ctx, places)
+        if err != nil {
+            return err
+        }
+        fmt.Fprintln(r.out, digits)
+
+    case "search":
+        if len(words) != 2 {
+            return fmt.Errorf("usage: search PATTERN")
+        }
+        if err := checkPattern(words[1]); err != nil {
+            return err
+        }
+        digits, err := r.prefix(ctx)
+        if err != nil {
+            return err
+        }
+        found := int64(0)
+        err = searchDigits(digits, words[1], func(position int64) bool {
+            found++
+            fmt.Fprintln(r.out, position)
+            return true
+        })
+        if err != nil {
+            return err
+        }
+        fmt.Fprintf(r.out, "%s within %d digits\n", occurrences(found),
+            digits.places)
+
+    case "stats":
+        if len(words) != 1 {
+            return fmt.Errorf("usage: stats")
+        }
+        digits, err := r.prefix(ctx)
+        if err != nil {
+            return err
+        }
+        stats := newDigitStats()
+        if err := stats.addAll(digits); err != nil {
+            return err
+        }
+        return stats.print(r.out)
+
+    case "help":
+        fmt.Fprintf(r.out, replHelp, defaultPlaces)
+
+    default:
+        return fmt.Errorf("unknown command %q, try help", words[0])
+    }
+    return nil
+}
+
+// Return pi with the given number of places, computed unless known
+func (r *repl) digits(ctx context.Context, places int) (string, error) {
+    return r.cache.extend(ctx, places)
+}
+
+// Return a reader of the digits computed so far, at least defaultPlaces
+func (r *repl) prefix(ctx context.Context) (*digitReader, error) {
+    if _, err := r.digits(ctx, defaultPlaces); err != nil {
+        return nil, err
+    }
+    digits := strings.NewReader(r.cache.longest())
+    return newDigitReader("digits", digits), nil
+}
@@ -0,0 +1,119 @@
+// Machine-readable reports of computations.
+
+package main
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "os"
+    "runtime"
+    "time"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+// What -report writes as JSON. The SHA-256 digest covers the digits as
+// printed, "3.1415...", without the trailing newline. The certified digits
+// are those guaranteed by the error bound of the formula, unknown with
+// -timeout.
+type runReport struct {
+    Digits       int         `json:"digits"`
+    Bits         int         `json:"bits,omitempty"`
+    Rounded      bool        `json:"rounded,omitempty"`
+    Base         int         `json:"base"`
+    Constant     string      `json:"constant"`
+    Expr         string      `json:"expression,omitempty"`
+    Algorithm    string      `json:"algorithm,omitempty"`
+    Formula      string      `json:"formula"`
+    WallTime     float64     `json:"wall_time_seconds"`
+    PeakMemory   uint64      `json:"peak_memory_bytes"`
+    SHA256       string      `json:"sha256"`
+    Certified    *int        `json:"certified_digits,omitempty"`
+    Spotcheck    string      `json:"bbp_spotcheck,omitempty"`
+    VerifiedWith string      `json:"verified_with,omitempty"`
+    Phases       *phaseTimes `json:"phases,omitempty"`
+
+    machine
+}
+
+// Where the wall time of a run went, with -stats
+type phaseTimes struct {
+    Series      float64 `json:"series_seconds"`
+    Combination float64 `json:"combination_seconds"`
+    Conversion  float64 `json:"conversion_seconds"`
+    Output      float64 `json:"output_seconds"`
+}
+
+// Log the time of every phase
+func (p *phaseTimes) log() {
+    for _, phase := range []struct {
+        name    string
+        seconds float64
+    }{
+        {"series", p.Series},
+        {"combination", p.Combination},
+        {"conversion", p.Conversion},
+        {"output", p.Output},
+    } {
+        d := time.Duration(phase.seconds * float64(time.Second))
+        slog.Info(fmt.Sprintf("%-12s %s", phase.name, roundDuration(d)),
+            "phase", phase.name, "seconds", phase.seconds)
+    }
+}
+
+// The machine a report comes from
+type machine struct {
+    GoVersion string `json:"go_version"`
+    OS        string `json:"os"`
+    Arch      string `json:"arch"`
+    CPUs      int    `json:"cpus"`
+    Procs     int    `json:"gomaxprocs,omitempty"`
+    Backend   string `json:"arithmetic"`
+    Time      string `json:"time"`
+}
+
+func newMachine() machine {
+    return machine{
+        GoVersion: runtime.Version(),
+        OS:        runtime.GOOS,
+        Arch:      runtime.GOARCH,
+        CPUs:      runtime.NumCPU(),
+        Procs:     runtime.GOMAXPROCS(0),
+        Backend:   pi.Backend,
+        Time:      time.Now().UTC().Format(time.RFC3339),
+    }
+}
+
+func newRunReport(constant, algo string, base int) *runReport {
+    if constant != "pi" {
+        // The algorithm only selects how pi is computed
+        algo = ""
+    }
+    return &runReport{
+        Base:      base,
+        Constant:  constant,
+        Algorithm: algo,
+        Formula:   pi.ConstantFormula(constant, algo),
+        machine:   newMachine(),
+    }
+}
+
+// Fill in the results of the computation
+func (r *runReport) finish(places int, digits string, elapsed time.Duration) {
+    sum := sha256.Sum256([]byte(digits))
+    r.Digits = places
+    r.SHA256 = hex.EncodeToString(sum[:])
+    r.WallTime = elapsed.Seconds()
+    r.PeakMemory = peakMemory()
+}
+
+func (r *runReport) write(name string) error {
+    data, err := json.MarshalIndent(r, "", "    ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(name, append(data, '\n'), 0644)
+}
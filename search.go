@@ -0,0 +1,151 @@
+// The search command: find digit sequences within pi.
+
+package main
+
+import (
+    "bufio"
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "log/slog"
+    "os"
+)
+
+const defaultSearchPlaces = 100000
+
+var searchCommand = &command{
+    name:  "search",
+    args:  "pattern [flags]",
+    short: "print every position where a digit sequence occurs",
+    setup: setupSearch,
+}
+
+func setupSearch(fs *flag.FlagSet) func(args []string) error {
+    places := fs.Int("max-digits", defaultSearchPlaces,
+        "search this many computed digits")
+    file := fs.String("file", "", "search the digits of this digit file")
+
+    return func(args []string) error {
+        if len(args) == 0 {
+            return usagef("expected exactly one pattern")
+        }
+        // The flags may follow the pattern as well
+        pattern := args[0]
+        if err := fs.Parse(args[1:]); err != nil {
+            return err
+        }
+        if fs.NArg() > 0 {
+            return usagef("expected exactly one pattern")
+        }
+        if err := checkPattern(pattern); err != nil {
+            return err
+        }
+        if *places < 0 {
+            return usagef("invalid number of digits %d", *places)
+        }
+
+        digits, closer, err := openDigits(*file, *places)
+        if err != nil {
+            return err
+        }
+        defer closer.Close()
+
+        // Positions are written while the digits stream by
+        w := bufio.NewWriter(os.Stdout)
+        found := int64(0)
+        err = searchDigits(digits, pattern, func(position int64) bool {
+            found++
+            fmt.Fprintln(w, position)
+            return true
+        })
+        if err != nil {
+            return err
+        }
+        if err := w.Flush(); err != nil {
+            return err
+        }
+        slog.Info(fmt.Sprintf("%s within %d digits", occurrences(found),
+            digits.places), "occurrences", found, "digits", digits.places)
+        return nil
+    }
+}
+
+// Return "1 occurrence" or "n occurrences"
+func occurrences(n int64) string {
+    if n == 1 {
+        return "1 occurrence"
+    }
+    return fmt.Sprintf("%d occurrences", n)
+}
+
+func checkPattern(pattern string) error {
+    if pattern == "" {
+        return usagef("empty pattern")
+    }
+    for _, c := range pattern {
+        if c < '0' || c > '9' {
+            return usagef("invalid pattern %q, only digits are allowed",
+                pattern)
+        }
+    }
+    return nil
+}
+
+// Call found with the position of every occurrence of pattern in the
+// digits, position 1 being the first digit after the decimal point, until
+// found returns false or the digits end
+func searchDigits(digits *digitReader, pattern string,
+    found func(position int64) bool) error {
+    m := newMatcher(pattern)
+    for {
+        c, err := digits.next()
+        if errors.Is(err, io.EOF) {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        if m.step(c) && !found(digits.places-int64(len(pattern))+1) {
+            return nil
+        }
+    }
+}
+
+// Knuth-Morris-Pratt matching of a pattern in a stream of bytes
+type matcher struct {
+    pattern []byte
+    fail    []int // length of the longest proper border of pattern[:i+1]
+    matched int   // length of the pattern prefix matched so far
+}
+
+func newMatcher(pattern string) *matcher {
+    m := &matcher{pattern: []byte(pattern), fail: make([]int, len(pattern))}
+    k := 0
+    for i := 1; i < len(pattern); i++ {
+        for k > 0 && pattern[i] != pattern[k] {
+            k = m.fail[k-1]
+        }
+        if pattern[i] == pattern[k] {
+            k++
+        }
+        m.fail[i] = k
+    }
+    return m
+}
+
+// Consume the next byte, return whether an occurrence of the pattern ends
+// with it
+func (m *matcher) step(c byte) bool {
+    for m.matched > 0 && c != m.pattern[m.matched] {
+        m.matched = m.fail[m.matched-1]
+    }
+    if c == m.pattern[m.matched] {
+        m.matched++
+    }
+    if m.matched == len(m.pattern) {
+        m.matched = m.fail[m.matched-1]
+        return true
+    }
+    return false
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "slices"
+    "strconv"
+    "strings"
+    "testing"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+func TestSearchFlagsAfterPattern(t *testing.T) {
+    name := filepath.Join(t.TempDir(), "pi.txt")
+    digits := pi.Digits(20000)
+    if err := os.WriteFile(name, []byte(digits), 0644); err != nil {
+        t.Fatal(err)
+    }
+    var want []string
+    places := digits[2:]
+    for i := 0; ; i++ {
+        j := strings.Index(places[i:], "1415926")
+        if j < 0 {
+            break
+        }
+        i += j
+        want = append(want, strconv.Itoa(i+1))
+    }
+
+    // The call of the request, on a file instead of ten million computed
+    // digits, and on computed digits
+    for _, c := range []struct {
+        args []string
+        want []string
+    }{
+        {[]string{"search", "-file", name, "1415926", "--max-digits",
+            "10000000"}, want},
+        {[]string{"search", "1415926", "--max-digits", "1000"},
+            []string{"1"}},
+    } {
+        out, err := runOutput(t, c.args...)
+        if err != nil {
+            t.Fatalf("%s: %v", strings.Join(c.args, " "), err)
+        }
+        if got := strings.Fields(out); !slices.Equal(got, c.want) {
+            t.Fatalf("%s: got %v, want %v", strings.Join(c.args, " "), got,
+                c.want)
+        }
+    }
+    if err := runCommand(t, "search", "14", "15"); err == nil {
+        t.Fatal("two patterns accepted")
+    }
+}
@@ -0,0 +1,65 @@
+// Built-in self test against embedded reference digits.
+
+package main
+
+import (
+    "context"
+    _ "embed"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+// The first 100000 decimal places of pi, computed independently with the
+// Chudnovsky formula
+//
+//go:embed reference/pi-100000.txt
+var referenceDigits string
+
+// Number of decimal places of referenceDigits
+const referencePlaces = 100000
+
+// Sizes of the self test computations, the last one covers the complete
+// reference
+var selftestSizes = []int{10, 100, 1000, 10000, referencePlaces}
+
+// Compute pi with every algorithm at every size of the self test and
+// compare the digits with the reference. Returns an error if any of them
+// does not match.
+func selftest(quiet bool) error {
+    failed, total := 0, 0
+    for _, algo := range pi.Algorithms() {
+        for _, places := range selftestSizes {
+            start := time.Now()
+            x, err := pi.Compute(context.Background(), places,
+                &pi.Options{Algorithm: algo})
+            if err != nil {
+                return err
+            }
+
+            got := newDigitReader(fmt.Sprintf("%s %d", algo, places),
+                strings.NewReader(pi.Format(x, places)))
+            want := newDigitReader("reference",
+                strings.NewReader(referenceDigits))
+            _, err = compareDigits(got, want)
+
+            total++
+            result := "PASS"
+            if err != nil {
+                failed++
+                result = "FAIL: " + err.Error()
+            }
+            if !quiet || err != nil {
+                fmt.Printf("%-12s %7d digits %8s  %s\n", algo, places,
+                    time.Since(start).Round(time.Millisecond), result)
+            }
+        }
+    }
+
+    if failed > 0 {
+        return fmt.Errorf("%d of %d self tests failed", failed, total)
+    }
+    return nil
+}
@@ -0,0 +1,341 @@
+// The serve command: digits of pi over HTTP.
+
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "log/slog"
+    "math"
+    "net/http"
+    "strconv"
+    "sync"
+    "time"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+var serveCommand = &command{
+    name:  "serve",
+    args:  "",
+    short: "serve digits of pi over HTTP: GET /v1/pi?digits=N, /metrics",
+    setup: setupServe,
+}
+
+func setupServe(fs *flag.FlagSet) func(args []string) error {
+    addr := fs.String("addr", "localhost:8080", "listen on this address")
+    profiling := fs.Bool("pprof", false,
+        "serve the profiles of the server under /debug/pprof/")
+    maxDigits := fs.Int("max-digits", 1000000,
+        "refuse requests for more digits, 0 for no limit")
+    rate := fs.Float64("rate", 10,
+        "allow every client this many requests per second, 0 for no limit")
+    burst := fs.Int("burst", 20,
+        "allow every client bursts of this many requests")
+
+    return func(args []string) error {
+        if len(args) > 0 {
+            return usagef("unexpected arguments")
+        }
+        if *maxDigits < 0 {
+            return usagef("invalid number of digits %d", *maxDigits)
+        }
+        if *rate < 0 || *burst < 1 {
+            return usagef("invalid rate %g or burst %d", *rate, *burst)
+        }
+
+        srv := &server{metrics: newServerMetrics(), maxDigits: *maxDigits}
+        srv.cache.metrics = srv.metrics
+        if *rate > 0 {
+            srv.limiter = newRateLimiter(*rate, *burst)
+        }
+        mux := http.NewServeMux()
+        mux.HandleFunc("/v1/pi", srv.handlePi)
+        mux.HandleFunc("/v1/pi/stream", srv.handleStream)
+        mux.Handle("/metrics", srv.metrics)
+        if *profiling {
+            handlePprof(mux)
+        }
+
+        slog.Info("serving on "+*addr, "addr", *addr)
+        return http.ListenAndServe(*addr, mux)
+    }
+}
+
+// Digits per message of the stream
+const streamChunk = 4096
+
+// The state shared by the handlers
+type server struct {
+    metrics   *serverMetrics
+    maxDigits int          // per request, 0 for no limit
+    limiter   *rateLimiter // nil for no limit
+    cache     digitCache
+}
+
+// The longest prefix of the digits of pi computed so far. The digits are
+// truncated, so the first digits of a longer prefix are the same as those
+// of a shorter one.
+//
+// Concurrent requests beyond the prefix share one computation extending
+// it: a request waits for the running computation, and if that falls
+// short, the next computation goes as far as the longest request waiting.
+type digitCache struct {
+    mu      sync.RWMutex
+    digits  string         // "3.14159..." or "3"
+    flight  *cacheFlight   // the running computation, nil if none
+    want    int            // most places of the requests waiting for more
+    metrics *serverMetrics // of the computations, nil for none
+}
+
+// A computation extending the cache
+type cacheFlight struct {
+    places  int
+    done    chan struct{} // closed once the computation is over
+    err     error         // of the computation, set before done closes
+    waiters int           // requests waiting for it
+    cancel  context.CancelFunc
+}
+
+// Return pi with the given number of places, if the cache has them
+func (c *digitCache) get(places int) (string, bool) {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.prefix(places)
+}
+
+// Same as get, with c.mu held
+func (c *digitCache) prefix(places int) (string, bool) {
+    if places == 0 && c.digits != "" {
+        return c.digits[:1], true
+    }
+    // Index 0 and 1 are the leading 3 and the decimal point
+    if len(c.digits) < places+2 {
+        return "", false
+    }
+    return c.digits[:places+2], true
+}
+
+// Return pi with the given number of places, from the cache or once a
+// computation has extended it far enough. The request gives up with
+// ctx.Err() when ctx is done, the computation is abandoned once all the
+// requests waiting for it have given up.
+func (c *digitCache) extend(ctx context.Context, places int) (string,
+    error) {
+    joined := false
+    for {
+        c.mu.Lock()
+        if digits, ok := c.prefix(places); ok {
+            c.mu.Unlock()
+            return digits, nil
+        }
+        f := c.flight
+        switch {
+        case f == nil:
+            f = c.start(max(places, c.want))
+            c.want = 0
+        default:
+            if !joined && c.metrics != nil {
+                c.metrics.coalesced.Add(1)
+            }
+            joined = true
+            if places > f.places {
+                c.want = max(c.want, places)
+            }
+        }
+        f.waiters++
+        c.mu.Unlock()
+
+        select {
+        case <-f.done:
+            if f.err != nil {
+                return "", f.err
+            }
+        case <-ctx.Done():
+            c.leave(f)
+            return "", ctx.Err()
+        }
+    }
+}
+
+// Start the computation of the given number of places, with c.mu held
+func (c *digitCache) start(places int) *cacheFlight {
+    ctx, cancel := context.WithCancel(context.Background())
+    f := &cacheFlight{places: places, done: make(chan struct{}),
+        cancel: cancel}
+    c.flight = f
+
+    go func() {
+        defer close(f.done)
+        if c.metrics != nil {
+            c.metrics.inFlight.Add(1)
+            defer c.metrics.inFlight.Add(-1)
+        }
+        start := time.Now()
+        digits, err := pi.DigitsCtx(ctx, places)
+        cancel()
+        if err == nil && c.metrics != nil {
+            c.metrics.observe(time.Since(start))
+        }
+
+        c.mu.Lock()
+        defer c.mu.Unlock()
+        f.err = err
+        if err == nil && len(digits) > len(c.digits) {
+            c.digits = digits
+        }
+        if c.flight == f {
+            c.flight = nil
+        }
+    }()
+    return f
+}
+
+// Stop waiting for the computation, abandoning it if nobody else waits
+func (c *digitCache) leave(f *cacheFlight) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    f.waiters--
+    if f.waiters == 0 && c.flight == f {
+        f.cancel()
+        c.flight, c.want = nil, 0
+    }
+}
+
+// Return the longest prefix, "" if there is none yet
+func (c *digitCache) longest() string {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.digits
+}
+
+// GET /v1/pi?digits=N
+func (srv *server) handlePi(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet && r.Method != http.MethodHead {
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    places, ok := srv.admit(w, r)
+    if !ok {
+        return
+    }
+
+    digits, ok := srv.cache.get(places)
+    if ok {
+        srv.metrics.cacheHits.Add(1)
+    } else {
+        // The request gives up when the client goes away
+        var err error
+        digits, err = srv.cache.extend(r.Context(), places)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusServiceUnavailable)
+            return
+        }
+    }
+
+    w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+    w.Write([]byte(digits + "\n"))
+    srv.metrics.digits.Add(int64(places))
+}
+
+// GET /v1/pi/stream?digits=N upgraded to a WebSocket: pi as text messages
+// of up to streamChunk digits, then the close. What the cache holds goes
+// out right away, the rest as the cache grows in steps of twice the digits
+// sent so far.
+func (srv *server) handleStream(w http.ResponseWriter, r *http.Request) {
+    places, ok := srv.admit(w, r)
+    if !ok {
+        return
+    }
+    ws, ok := upgradeWebSocket(w, r)
+    if !ok {
+        return
+    }
+
+    // The hijacked connection does not cancel the request context, the
+    // computation is given up when the client goes away
+    ctx, cancel := context.WithCancel(r.Context())
+    defer cancel()
+    go func() {
+        select {
+        case <-ws.done:
+            cancel()
+        case <-ctx.Done():
+        }
+    }()
+
+    // The leading 3 and the point, or the 3 alone
+    total := places + 2
+    if places == 0 {
+        total = 1
+    }
+    digits := srv.cache.longest()
+    if len(digits) >= total {
+        srv.metrics.cacheHits.Add(1)
+    }
+    for sent := 0; sent < total; {
+        if len(digits) <= sent {
+            var err error
+            digits, err = srv.cache.extend(ctx,
+                min(places, max(streamChunk, 2*sent)))
+            if err != nil {
+                select {
+                case <-ws.done:
+                    ws.conn.Close()
+                default:
+                    // Internal error
+                    ws.close(1011)
+                }
+                return
+            }
+        }
+        for end := min(len(digits), total); sent < end; {
+            n := min(end-sent, streamChunk)
+            select {
+            case <-ws.done:
+                ws.conn.Close()
+                return
+            default:
+            }
+            if err := ws.writeText([]byte(digits[sent : sent+n])); err != nil {
+                ws.conn.Close()
+                return
+            }
+            sent += n
+        }
+    }
+    srv.metrics.digits.Add(int64(places))
+    ws.close(1000)
+}
+
+// Return the number of places asked for by the digits parameter, or
+// answer the request with an error if it asks for too many or the client
+// is over its rate
+func (srv *server) admit(w http.ResponseWriter, r *http.Request) (int,
+    bool) {
+    places := defaultPlaces
+    if s := r.URL.Query().Get("digits"); s != "" {
+        x, err := parsePlaces(s)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return 0, false
+        }
+        places = x
+    }
+    if srv.maxDigits > 0 && places > srv.maxDigits {
+        http.Error(w, fmt.Sprintf("at most %d digits per request",
+            srv.maxDigits), http.StatusBadRequest)
+        return 0, false
+    }
+    if srv.limiter != nil {
+        if ok, wait := srv.limiter.allow(clientOf(r)); !ok {
+            seconds := int(math.Ceil(wait.Seconds()))
+            w.Header().Set("Retry-After", strconv.Itoa(seconds))
+            http.Error(w, "too many requests", http.StatusTooManyRequests)
+            return 0, false
+        }
+    }
+    srv.metrics.requests.Add(1)
+    return places, true
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+    "bufio"
+    "encoding/binary"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "testing"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+// Return the text messages of the WebSocket stream of the given URL path
+func readStream(t *testing.T, addr, path string) []string {
+    t.Helper()
+    conn, err := net.Dial("tcp", addr)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: "+addr+"\r\n"+
+        "Connection: Upgrade\r\nUpgrade: websocket\r\n"+
+        "Sec-WebSocket-Version: 13\r\n"+
+        "Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+    r := bufio.NewReader(conn)
+    resp, err := http.ReadResponse(r, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if resp.StatusCode != http.StatusSwitchingProtocols {
+        t.Fatalf("upgrade: %s", resp.Status)
+    }
+
+    // The frames of the server are not masked
+    var messages []string
+    for {
+        var head [2]byte
+        if _, err := io.ReadFull(r, head[:]); err != nil {
+            t.Fatal(err)
+        }
+        n := uint64(head[1] & 0x7f)
+        switch n {
+        case 126:
+            var ext [2]byte
+            io.ReadFull(r, ext[:])
+            n = uint64(binary.BigEndian.Uint16(ext[:]))
+        case 127:
+            var ext [8]byte
+            io.ReadFull(r, ext[:])
+            n = binary.BigEndian.Uint64(ext[:])
+        }
+        payload := make([]byte, n)
+        if _, err := io.ReadFull(r, payload); err != nil {
+            t.Fatal(err)
+        }
+        switch head[0] & 0x0f {
+        case 1:
+            messages = append(messages, string(payload))
+        case 8:
+            return messages
+        }
+    }
+}
+
+func TestStream(t *testing.T) {
+    srv := &server{metrics: newServerMetrics()}
+    srv.cache.metrics = srv.metrics
+    ts := httptest.NewServer(http.HandlerFunc(srv.handleStream))
+    defer ts.Close()
+    addr := strings.TrimPrefix(ts.URL, "http://")
+
+    // Computed in steps, then partly from the cache
+    for _, places := range []int{1, 10000, 30000} {
+        messages := readStream(t, addr, "/v1/pi/stream?digits="+
+            strconv.Itoa(places))
+        if got := strings.Join(messages, ""); got != pi.Digits(places) {
+            t.Fatalf("%d places: got %d bytes of %d messages", places,
+                len(got), len(messages))
+        }
+        for _, m := range messages {
+            if len(m) > streamChunk {
+                t.Fatalf("%d places: message of %d digits", places, len(m))
+            }
+        }
+    }
+    if n := len(srv.cache.longest()); n != 30002 {
+        t.Fatalf("cache of %d bytes after the streams", n)
+    }
+}
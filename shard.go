@@ -0,0 +1,162 @@
+// Splitting the output of huge runs in shards, for -shard-size.
+//
+// The places after the point go to numbered files of -shard-size digits
+// each, named after -output: pi.txt.0001, pi.txt.0002 and so on. A shard
+// holds nothing but its digits, no newline, so that sha256sum of an
+// uncompressed shard matches its checksum. The manifest pi.txt.manifest
+// lists the shards in JSON with their first places and checksums. Where a
+// command reads a digit file, the manifest stands for all the shards.
+
+package main
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// Appended to -output for the name of the manifest
+const shardManifestSuffix = ".manifest"
+
+// The manifest of a sharded output
+type shardManifest struct {
+    Constant  string       `json:"constant"`
+    Algorithm string       `json:"algorithm,omitempty"`
+    Base      int          `json:"base"`
+    Integer   string       `json:"integer_part"`
+    Digits    int          `json:"digits"`
+    ShardSize int          `json:"shard_size"`
+    Shards    []shardEntry `json:"shards"`
+}
+
+// A shard in the manifest, the checksum covers the uncompressed digits
+type shardEntry struct {
+    File   string `json:"file"`
+    First  int    `json:"first_place"`
+    Digits int    `json:"digits"`
+    SHA256 string `json:"sha256"`
+}
+
+// Write the digits as shards named after -output and their manifest
+func (f *computeFlags) writeShards(digits string, report *runReport) error {
+    integer, places, _ := strings.Cut(digits, ".")
+    count := max(1, (len(places)+f.shardSize-1)/f.shardSize)
+    width := max(4, len(fmt.Sprint(count)))
+
+    manifest := &shardManifest{
+        Constant:  report.Constant,
+        Algorithm: report.Algorithm,
+        Base:      f.base,
+        Integer:   integer,
+        Digits:    len(places),
+        ShardSize: f.shardSize,
+    }
+    for i := 0; i < count; i++ {
+        shard := places[min(i*f.shardSize, len(places)):]
+        shard = shard[:min(len(shard), f.shardSize)]
+        name := fmt.Sprintf("%s.%0*d%s", f.output, width, i+1,
+            compressedSuffix(f.compress))
+
+        write := compressed(f.compress, func(w io.Writer) error {
+            _, err := io.WriteString(w, shard)
+            return err
+        })
+        if err := writeOutput(name, write); err != nil {
+            return err
+        }
+        sum := sha256.Sum256([]byte(shard))
+        manifest.Shards = append(manifest.Shards, shardEntry{
+            File:   filepath.Base(name),
+            First:  i*f.shardSize + 1,
+            Digits: len(shard),
+            SHA256: hex.EncodeToString(sum[:]),
+        })
+    }
+
+    data, err := json.MarshalIndent(manifest, "", "    ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(f.output+shardManifestSuffix, append(data, '\n'),
+        0644)
+}
+
+// Open the digits of the sharded output with the named manifest, as one
+// digit file
+func openShards(name string) (*digitReader, io.Closer, error) {
+    data, err := os.ReadFile(name)
+    if err != nil {
+        return nil, nil, err
+    }
+    var m shardManifest
+    if err := json.Unmarshal(data, &m); err != nil {
+        return nil, nil, fmt.Errorf("%s: %v", name, err)
+    }
+    r := &shardReader{
+        dir:    filepath.Dir(name),
+        shards: m.Shards,
+        head:   strings.NewReader(m.Integer + "."),
+    }
+    d := newDigitReader(name, r)
+    d.total = int64(m.Digits)
+    if m.Base != 0 {
+        d.base = m.Base
+    }
+    return d, r, nil
+}
+
+// Reads the integer part and the point, then the shards one after the
+// other, each open only while it is read
+type shardReader struct {
+    dir    string
+    shards []shardEntry // still to be opened
+    head   io.Reader
+    shard  io.Reader
+    closer io.Closer
+}
+
+func (s *shardReader) Read(p []byte) (int, error) {
+    if s.head != nil {
+        n, err := s.head.Read(p)
+        if err != io.EOF {
+            return n, err
+        }
+        s.head = nil
+    }
+    for {
+        if s.shard != nil {
+            n, err := s.shard.Read(p)
+            if err != io.EOF {
+                return n, err
+            }
+            s.closer.Close()
+            s.shard, s.closer = nil, nil
+            if n > 0 {
+                return n, nil
+            }
+        }
+        if len(s.shards) == 0 {
+            return 0, io.EOF
+        }
+        r, closer, _, err := openDecompressed(filepath.Join(s.dir,
+            s.shards[0].File))
+        if err != nil {
+            return 0, err
+        }
+        s.shards = s.shards[1:]
+        s.shard, s.closer = r, closer
+    }
+}
+
+// Close the shard being read
+func (s *shardReader) Close() error {
+    if s.closer == nil {
+        return nil
+    }
+    return s.closer.Close()
+}
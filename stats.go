@@ -0,0 +1,142 @@
+// The stats command: frequencies of digits, digit pairs and runs.
+
+package main
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "text/tabwriter"
+)
+
+var statsCommand = &command{
+    name:  "stats",
+    args:  "",
+    short: "print digit frequencies, longest runs and normality tests",
+    setup: setupStats,
+}
+
+func setupStats(fs *flag.FlagSet) func(args []string) error {
+    places := fs.Int("digits", defaultPlaces,
+        "analyze this many computed digits")
+    file := fs.String("file", "", "analyze the digits of this digit file")
+
+    return func(args []string) error {
+        if len(args) > 0 {
+            return usagef("unexpected arguments")
+        }
+        if *places < 0 {
+            return usagef("invalid number of digits %d", *places)
+        }
+
+        digits, closer, err := openDigits(*file, *places)
+        if err != nil {
+            return err
+        }
+        defer closer.Close()
+
+        stats := newDigitStats()
+        if err := stats.addAll(digits); err != nil {
+            return err
+        }
+        return stats.print(os.Stdout)
+    }
+}
+
+// A run of equal digits
+type digitRun struct {
+    length   int64
+    position int64 // of the first digit, 1-based
+}
+
+// Statistics of a sequence of decimal digits
+type digitStats struct {
+    count   int64
+    freq    [10]int64
+    pairs   [10][10]int64 // pairs[a][b] counts a followed by b
+    prev    int           // previous digit, -1 at the start
+    run     int64         // length of the current run
+    longest [10]digitRun  // per digit, the first of the longest runs
+
+    normality normalityCounters
+}
+
+func newDigitStats() *digitStats {
+    return &digitStats{prev: -1}
+}
+
+// Add the next digit, 0 to 9
+func (s *digitStats) add(d int) {
+    s.count++
+    s.freq[d]++
+
+    if d == s.prev {
+        s.run++
+        s.pairs[d][d]++
+    } else {
+        if s.prev >= 0 {
+            s.pairs[s.prev][d]++
+        }
+        s.run = 1
+    }
+    if s.run > s.longest[d].length {
+        s.longest[d] = digitRun{s.run, s.count - s.run + 1}
+    }
+    s.prev = d
+
+    s.normality.add(d, s.count)
+}
+
+// Add all digits of r
+func (s *digitStats) addAll(r *digitReader) error {
+    for {
+        c, err := r.next()
+        if errors.Is(err, io.EOF) {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        s.add(int(c - '0'))
+    }
+}
+
+func (s *digitStats) print(out io.Writer) error {
+    w := tabwriter.NewWriter(out, 0, 8, 2, ' ', tabwriter.AlignRight)
+
+    fmt.Fprintf(w, "%d digits\n\n", s.count)
+    fmt.Fprintf(w, "digit\tcount\tfrequency\tlongest run\tat digit\t\n")
+    for d := 0; d < 10; d++ {
+        fmt.Fprintf(w, "%d\t%d\t%.5f\t%d\t%d\t\n", d, s.freq[d],
+            ratio(s.freq[d], s.count), s.longest[d].length,
+            s.longest[d].position)
+    }
+
+    fmt.Fprintf(w, "\npairs\t")
+    for b := 0; b < 10; b++ {
+        fmt.Fprintf(w, "%d\t", b)
+    }
+    fmt.Fprintln(w)
+    for a := 0; a < 10; a++ {
+        fmt.Fprintf(w, "%d\t", a)
+        for b := 0; b < 10; b++ {
+            fmt.Fprintf(w, "%d\t", s.pairs[a][b])
+        }
+        fmt.Fprintln(w)
+    }
+    fmt.Fprintln(w)
+    if err := w.Flush(); err != nil {
+        return err
+    }
+
+    return printTests(out, s.normalityTests())
+}
+
+func ratio(a, b int64) float64 {
+    if b == 0 {
+        return 0
+    }
+    return float64(a) / float64(b)
+}
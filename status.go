@@ -0,0 +1,74 @@
+// Status reports on request: while compute runs, a signal, SIGUSR1 on
+// Unix, or Enter on the console on Windows, prints where it stands on
+// stderr without interrupting it. The platform specific files provide
+// notifyStatus.
+
+package main
+
+import (
+    "fmt"
+    "log/slog"
+    "runtime"
+    "sync"
+    "time"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+type statusReporter struct {
+    start time.Time
+
+    mu       sync.Mutex
+    progress pi.Progress // the latest
+    forward  func(pi.Progress)
+}
+
+// Return a reporter passing the progress on to forward, if not nil
+func newStatusReporter(forward func(pi.Progress)) *statusReporter {
+    return &statusReporter{start: time.Now(), forward: forward}
+}
+
+// Keep the latest progress
+func (s *statusReporter) update(progress pi.Progress) {
+    s.mu.Lock()
+    s.progress = progress
+    s.mu.Unlock()
+    if s.forward != nil {
+        s.forward(progress)
+    }
+}
+
+// Print the terms, the digits converged so far, the time and the memory
+func (s *statusReporter) print() {
+    s.mu.Lock()
+    progress := s.progress
+    s.mu.Unlock()
+    var stats runtime.MemStats
+    runtime.ReadMemStats(&stats)
+
+    state := "starting"
+    switch fraction := progress.Fraction(); {
+    case progress.ExpectedTerms == 0:
+    case progress.Terms >= progress.ExpectedTerms:
+        state = fmt.Sprintf("%d terms done, combining and converting",
+            progress.Terms)
+    default:
+        // Every term of the series adds about the same number of digits
+        state = fmt.Sprintf("%d/%d terms, about %.0f of %d digits "+
+            "converged", progress.Terms, progress.ExpectedTerms,
+            fraction*float64(progress.Places), progress.Places)
+    }
+    elapsed := time.Since(s.start)
+    slog.Info(fmt.Sprintf("status: %s, %s elapsed, heap %s, peak %s", state,
+        elapsed.Round(time.Second), formatBytes(stats.HeapAlloc),
+        formatBytes(peakMemory())), "terms", progress.Terms,
+        "expected_terms", progress.ExpectedTerms,
+        "seconds", elapsed.Seconds(), "heap_bytes", stats.HeapAlloc,
+        "peak_bytes", peakMemory())
+}
+
+// Print the status whenever it is asked for, until the returned function
+// is called
+func (s *statusReporter) watch() (stop func()) {
+    return notifyStatus(s.print)
+}
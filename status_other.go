@@ -0,0 +1,8 @@
+//go:build !unix && !windows
+
+package main
+
+// No way to ask for the status
+func notifyStatus(request func()) (stop func()) {
+    return func() {}
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package main
+
+import (
+    "os"
+    "os/signal"
+    "syscall"
+)
+
+// Call request for every SIGUSR1 until the returned function is called
+func notifyStatus(request func()) (stop func()) {
+    signals := make(chan os.Signal, 1)
+    signal.Notify(signals, syscall.SIGUSR1)
+    done := make(chan struct{})
+    go func() {
+        for {
+            select {
+            case <-signals:
+                request()
+            case <-done:
+                return
+            }
+        }
+    }()
+    return func() {
+        signal.Stop(signals)
+        close(done)
+    }
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+    "bufio"
+    "os"
+    "sync/atomic"
+)
+
+// Call request for every line entered on the console until the returned
+// function is called; Windows has no signal to spare
+func notifyStatus(request func()) (stop func()) {
+    info, err := os.Stdin.Stat()
+    if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+        return func() {}
+    }
+    var stopped atomic.Bool
+    go func() {
+        // The read cannot be interrupted, the goroutine ends with the
+        // process or the next line
+        lines := bufio.NewScanner(os.Stdin)
+        for lines.Scan() && !stopped.Load() {
+            request()
+        }
+    }()
+    return func() {
+        stopped.Store(true)
+    }
+}
@@ -0,0 +1,59 @@
+// The trace of compute -trace-terms: one CSV row per series term, for
+// studying how the series converge.
+//
+//    series,index,magnitude_digits,certified_digits,elapsed_seconds
+//    arccot(5),0,0.699,2.097,0.000012
+//
+// The magnitude is the size of the term in decimal digits, -log10 |term|,
+// the certified digits those of the series the terms so far guarantee,
+// see pi.Term, and the time is counted from the start of the computation.
+
+package main
+
+import (
+    "encoding/csv"
+    "os"
+    "strconv"
+    "time"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+type traceWriter struct {
+    file  *os.File
+    csv   *csv.Writer
+    start time.Time
+}
+
+// Create the trace file and write the header
+func createTrace(name string) (*traceWriter, error) {
+    f, err := os.Create(name)
+    if err != nil {
+        return nil, err
+    }
+    t := &traceWriter{f, csv.NewWriter(f), time.Now()}
+    t.csv.Write([]string{"series", "index", "magnitude_digits",
+        "certified_digits", "elapsed_seconds"})
+    return t, nil
+}
+
+// Write the row of a term, errors show up in close
+func (t *traceWriter) term(term pi.Term) {
+    t.csv.Write([]string{
+        term.Series,
+        strconv.Itoa(term.Index),
+        strconv.FormatFloat(term.Magnitude, 'f', 3, 64),
+        strconv.FormatFloat(term.Certified, 'f', 3, 64),
+        strconv.FormatFloat(time.Since(t.start).Seconds(), 'f', 6, 64),
+    })
+}
+
+// Flush the rows and close the file
+func (t *traceWriter) close() error {
+    t.csv.Flush()
+    err := t.csv.Error()
+    if cerr := t.file.Close(); err == nil {
+        err = cerr
+    }
+    return err
+}
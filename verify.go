@@ -0,0 +1,126 @@
+// The verify command: compare a digit file against a reference.
+
+package main
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+)
+
+var verifyCommand = &command{
+    name:  "verify",
+    args:  "",
+    short: "compare a digit file against a reference, or run the self test",
+    setup: setupVerify,
+}
+
+func setupVerify(fs *flag.FlagSet) func(args []string) error {
+    file := fs.String("file", "", "digit file to verify")
+    reference := fs.String("reference", "",
+        "digit file known to be correct, by default the reference digits\n"+
+            "are computed")
+    self := fs.Bool("selftest", false,
+        "instead of verifying a file, compare every algorithm at several\n"+
+            "sizes against the embedded reference digits")
+    quiet := fs.Bool("quiet", false, "with -selftest, only report failures")
+
+    return func(args []string) error {
+        if len(args) > 0 {
+            return usagef("unexpected arguments")
+        }
+        if *self {
+            if *file != "" || *reference != "" {
+                return usagef("-selftest takes neither -file nor -reference")
+            }
+            return selftest(*quiet)
+        }
+        if *file == "" {
+            return usagef("missing -file")
+        }
+
+        digits, f, err := openDigitFile(*file)
+        if err != nil {
+            return err
+        }
+        defer f.Close()
+
+        var ref *digitReader
+        if *reference != "" {
+            r, rf, err := openDigitFile(*reference)
+            if err != nil {
+                return err
+            }
+            defer rf.Close()
+            if err := sameBase(digits, r); err != nil {
+                return err
+            }
+            ref = r
+        } else {
+            places := digits.total
+            if places == 0 {
+                places = digits.size
+            }
+            if places == 0 {
+                return fmt.Errorf("%s: unknown number of digits, compare "+
+                    "it with -reference", *file)
+            }
+            ref, err = computedDigits(int(places), digits.base)
+            if err != nil {
+                return err
+            }
+        }
+
+        matching, err := compareDigits(digits, ref)
+        if err != nil {
+            return err
+        }
+        fmt.Printf("all %d digits match\n", matching)
+        return nil
+    }
+}
+
+// A difference between two digit streams
+type mismatchError struct {
+    place      int64 // 1-based decimal place of the first difference
+    got, want  byte  // 0 if the stream ended
+    wantSource string
+}
+
+func (e *mismatchError) Error() string {
+    if e.want == 0 {
+        return fmt.Sprintf("%d digits match, %s ends before digit %d",
+            e.place-1, e.wantSource, e.place)
+    }
+    return fmt.Sprintf("first mismatch at digit %d: %c instead of %c, "+
+        "%d digits match", e.place, e.got, e.want, e.place-1)
+}
+
+// Compare all digits of got against want. Returns the number of digits
+// of got, or a *mismatchError at the first difference.
+func compareDigits(got, want *digitReader) (int64, error) {
+    for {
+        g, err := got.next()
+        if errors.Is(err, io.EOF) {
+            return got.places, nil
+        }
+        if err != nil {
+            return 0, err
+        }
+
+        w, err := want.next()
+        if errors.Is(err, io.EOF) {
+            return 0, &mismatchError{place: got.places, got: g,
+                wantSource: want.name}
+        }
+        if err != nil {
+            return 0, err
+        }
+
+        if g != w {
+            return 0, &mismatchError{place: got.places, got: g, want: w,
+                wantSource: want.name}
+        }
+    }
+}
@@ -0,0 +1,185 @@
+// The viz command: pictures of the digits.
+//
+// The walk takes a step of unit length for every digit, digit d turning
+// it to the direction d times 36 degrees, and is scaled to fit the image;
+// its color moves through the hues from red at the first step to violet
+// at the last. The raster paints every digit as a square of its own
+// color, row by row.
+
+package main
+
+import (
+    "errors"
+    "flag"
+    "image"
+    "image/color"
+    "image/draw"
+    "image/png"
+    "io"
+    "math"
+    "os"
+)
+
+const defaultVizPlaces = 100000
+
+var vizCommand = &command{
+    name:  "viz",
+    args:  "",
+    short: "render the digits as random walk or raster PNG",
+    setup: setupViz,
+}
+
+// The colors of the digits in the raster, a palette telling ten apart
+var digitColors = [10]color.RGBA{
+    {0x1f, 0x77, 0xb4, 0xff}, {0xff, 0x7f, 0x0e, 0xff},
+    {0x2c, 0xa0, 0x2c, 0xff}, {0xd6, 0x27, 0x28, 0xff},
+    {0x94, 0x67, 0xbd, 0xff}, {0x8c, 0x56, 0x4b, 0xff},
+    {0xe3, 0x77, 0xc2, 0xff}, {0x7f, 0x7f, 0x7f, 0xff},
+    {0xbc, 0xbd, 0x22, 0xff}, {0x17, 0xbe, 0xcf, 0xff},
+}
+
+func setupViz(fs *flag.FlagSet) func(args []string) error {
+    places := fs.Int("digits", defaultVizPlaces,
+        "render this many computed digits")
+    file := fs.String("file", "", "render the digits of this digit file")
+    out := fs.String("out", "walk.png", "write the PNG to this file")
+    mode := fs.String("mode", "walk",
+        "walk for the random walk, raster for a square of color per digit")
+    size := fs.Int("size", 1024, "width and height of the walk in pixels")
+    scale := fs.Int("scale", 2, "width and height of a digit of the raster")
+
+    return func(args []string) error {
+        if len(args) > 0 {
+            return usagef("unexpected arguments")
+        }
+        if *places < 0 {
+            return usagef("invalid number of digits %d", *places)
+        }
+        if *size < 1 || *scale < 1 {
+            return usagef("invalid size %d or scale %d", *size, *scale)
+        }
+        var render func(digits []byte, pixels int) image.Image
+        pixels := *size
+        switch *mode {
+        case "walk":
+            render = renderWalk
+        case "raster":
+            render, pixels = renderRaster, *scale
+        default:
+            return usagef("unknown mode %q, choose walk or raster", *mode)
+        }
+
+        r, closer, err := openDigits(*file, *places)
+        if err != nil {
+            return err
+        }
+        defer closer.Close()
+        digits, err := readAllDigits(r)
+        if err != nil {
+            return err
+        }
+        if len(digits) == 0 {
+            return usagef("no digits to render")
+        }
+
+        f, err := os.Create(*out)
+        if err != nil {
+            return err
+        }
+        defer f.Close()
+        if err := png.Encode(f, render(digits, pixels)); err != nil {
+            return err
+        }
+        return f.Close()
+    }
+}
+
+// Return the digits after the point, 0 to 9
+func readAllDigits(r *digitReader) ([]byte, error) {
+    var digits []byte
+    for {
+        c, err := r.next()
+        if errors.Is(err, io.EOF) {
+            return digits, nil
+        }
+        if err != nil {
+            return nil, err
+        }
+        digits = append(digits, c-'0')
+    }
+}
+
+// Draw the walk on a white square of the given size
+func renderWalk(digits []byte, size int) image.Image {
+    // The path, then its bounding box
+    xs := make([]float64, len(digits)+1)
+    ys := make([]float64, len(digits)+1)
+    for i, d := range digits {
+        angle := float64(d) * math.Pi / 5
+        xs[i+1] = xs[i] + math.Cos(angle)
+        ys[i+1] = ys[i] + math.Sin(angle)
+    }
+    minX, maxX, minY, maxY := 0.0, 0.0, 0.0, 0.0
+    for i := range xs {
+        minX, maxX = math.Min(minX, xs[i]), math.Max(maxX, xs[i])
+        minY, maxY = math.Min(minY, ys[i]), math.Max(maxY, ys[i])
+    }
+    margin := 0.02 * float64(size)
+    scale := (float64(size) - 2*margin) / math.Max(math.Max(maxX-minX,
+        maxY-minY), 1)
+
+    img := image.NewRGBA(image.Rect(0, 0, size, size))
+    draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+    pixel := func(x, y float64) (float64, float64) {
+        // Up is up, unlike in the image
+        return margin + (x-minX)*scale,
+            float64(size) - margin - (y-minY)*scale
+    }
+    for i := range digits {
+        c := hue(0.8 * float64(i) / float64(len(digits)))
+        x0, y0 := pixel(xs[i], ys[i])
+        x1, y1 := pixel(xs[i+1], ys[i+1])
+        // Enough points for a line without gaps
+        steps := int(math.Ceil(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))))
+        for s := 0; s <= steps; s++ {
+            t := float64(s) / float64(max(steps, 1))
+            img.Set(int(x0+t*(x1-x0)), int(y0+t*(y1-y0)), c)
+        }
+    }
+    return img
+}
+
+// Paint every digit as a square of size pixels, in rows of the square
+// root of the number of digits
+func renderRaster(digits []byte, size int) image.Image {
+    columns := int(math.Ceil(math.Sqrt(float64(len(digits)))))
+    rows := (len(digits) + columns - 1) / columns
+    img := image.NewRGBA(image.Rect(0, 0, columns*size, rows*size))
+    draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+    for i, d := range digits {
+        x, y := i%columns*size, i/columns*size
+        square := image.Rect(x, y, x+size, y+size)
+        draw.Draw(img, square, image.NewUniform(digitColors[d]),
+            image.Point{}, draw.Src)
+    }
+    return img
+}
+
+// Return the fully saturated color of the hue, 0 for red up to 1
+func hue(h float64) color.RGBA {
+    h = 6 * math.Mod(h, 1)
+    x := uint8(255 * (1 - math.Abs(math.Mod(h, 2)-1)))
+    switch int(h) {
+    case 0:
+        return color.RGBA{255, x, 0, 255}
+    case 1:
+        return color.RGBA{x, 255, 0, 255}
+    case 2:
+        return color.RGBA{0, 255, x, 255}
+    case 3:
+        return color.RGBA{0, x, 255, 255}
+    case 4:
+        return color.RGBA{x, 0, 255, 255}
+    }
+    return color.RGBA{255, 0, x, 255}
+}
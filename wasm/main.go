@@ -0,0 +1,117 @@
+//go:build js && wasm
+
+// Pi in the browser: built with GOOS=js GOARCH=wasm, this program defines
+// the JavaScript function
+//
+//    computePi(digits, onChunk)
+//
+// computing pi with the given number of places and passing the digits
+// "3.14159..." to onChunk in pieces. It returns a promise resolving to the
+// number of places once the last piece is passed, or rejecting with the
+// error of the computation.
+//
+// Go on WebAssembly runs in the thread of the page, the page only gets to
+// handle its events when Go waits. The computation therefore waits for the
+// event loop every wasmSlice, and so does the delivery after every piece.
+
+package main
+
+import (
+    "context"
+    "syscall/js"
+    "time"
+
+    "github.com/miromotl/pi_by_digits/pi"
+)
+
+// Length of the pieces passed to onChunk
+const wasmChunk = 1 << 14
+
+// Longest time the page is kept waiting
+const wasmSlice = 50 * time.Millisecond
+
+func main() {
+    js.Global().Set("computePi", js.FuncOf(computePi))
+    // Keep the functions alive for the page
+    select {}
+}
+
+// computePi(digits, onChunk) in JavaScript
+func computePi(this js.Value, args []js.Value) any {
+    if len(args) != 2 || args[0].Type() != js.TypeNumber ||
+        args[1].Type() != js.TypeFunction {
+        return rejected("usage: computePi(digits, onChunk)")
+    }
+    places := args[0].Int()
+    if places < 0 {
+        return rejected("invalid number of digits")
+    }
+    onChunk := args[1]
+
+    return newPromise(func(resolve, reject js.Value) {
+        digits, err := compute(places)
+        if err != nil {
+            reject.Invoke(jsError(err.Error()))
+            return
+        }
+        for len(digits) > 0 {
+            n := min(len(digits), wasmChunk)
+            onChunk.Invoke(digits[:n])
+            digits = digits[n:]
+            yield()
+        }
+        resolve.Invoke(places)
+    })
+}
+
+// Return pi with the given number of places, letting the page handle its
+// events while computing
+func compute(places int) (string, error) {
+    last := time.Now()
+    opts := &pi.Options{Progress: func(pi.Progress) {
+        if time.Since(last) >= wasmSlice {
+            yield()
+            last = time.Now()
+        }
+    }}
+    x, err := pi.Compute(context.Background(), places, opts)
+    if err != nil {
+        return "", err
+    }
+    return pi.Format(x, places), nil
+}
+
+// Wait for the event loop of the page to come round
+func yield() {
+    done := make(chan struct{})
+    var f js.Func
+    f = js.FuncOf(func(this js.Value, args []js.Value) any {
+        f.Release()
+        close(done)
+        return nil
+    })
+    js.Global().Call("setTimeout", f, 0)
+    <-done
+}
+
+// Return a promise settled by run in a goroutine of its own, the callers
+// of Go functions must not be blocked
+func newPromise(run func(resolve, reject js.Value)) js.Value {
+    var executor js.Func
+    executor = js.FuncOf(func(this js.Value, args []js.Value) any {
+        executor.Release()
+        go run(args[0], args[1])
+        return nil
+    })
+    return js.Global().Get("Promise").New(executor)
+}
+
+// Return a promise rejected with the message
+func rejected(message string) js.Value {
+    return js.Global().Get("Promise").Call("reject", jsError(message))
+}
+
+// Return a JavaScript Error with the message
+func jsError(message string) js.Value {
+    return js.Global().Get("Error").New(message)
+}
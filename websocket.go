@@ -0,0 +1,214 @@
+// The server side of the WebSocket protocol, RFC 6455, as far as the
+// stream of digits needs it: the upgrade of a request, unfragmented text
+// messages to the client and the close handshake. Messages of the client
+// are read for pings and the close only.
+
+package main
+
+import (
+    "bufio"
+    "crypto/sha1"
+    "encoding/base64"
+    "encoding/binary"
+    "errors"
+    "io"
+    "net"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Appended to the key of the client for the accept header
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Frame opcodes
+const (
+    wsText  = 0x1
+    wsClose = 0x8
+    wsPing  = 0x9
+    wsPong  = 0xa
+)
+
+// Longest message accepted from the client, control frames are shorter
+const wsMaxRead = 125
+
+// Writes to a client wait this long at most
+const wsWriteTimeout = 30 * time.Second
+
+// A WebSocket connection to a client. Done is closed once the client is
+// gone or has closed the connection.
+type wsConn struct {
+    conn net.Conn
+    r    *bufio.Reader
+    done chan struct{}
+
+    mu     sync.Mutex // for writing
+    closed bool       // the close frame has been sent
+}
+
+// Return whether the comma separated header values contain the token
+func headerHas(h http.Header, name, token string) bool {
+    for _, value := range h.Values(name) {
+        for _, t := range strings.Split(value, ",") {
+            if strings.EqualFold(strings.TrimSpace(t), token) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// Upgrade the request to a WebSocket connection, answering it with an
+// error if it is no valid upgrade
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn,
+    bool) {
+    key := r.Header.Get("Sec-WebSocket-Key")
+    switch {
+    case r.Method != http.MethodGet:
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return nil, false
+    case !headerHas(r.Header, "Connection", "upgrade") ||
+        !headerHas(r.Header, "Upgrade", "websocket") || key == "":
+        http.Error(w, "WebSocket upgrade expected", http.StatusBadRequest)
+        return nil, false
+    case r.Header.Get("Sec-WebSocket-Version") != "13":
+        w.Header().Set("Sec-WebSocket-Version", "13")
+        http.Error(w, "unsupported WebSocket version",
+            http.StatusUpgradeRequired)
+        return nil, false
+    }
+    hijacker, ok := w.(http.Hijacker)
+    if !ok {
+        http.Error(w, "WebSocket not supported", http.StatusInternalServerError)
+        return nil, false
+    }
+    conn, rw, err := hijacker.Hijack()
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return nil, false
+    }
+
+    sum := sha1.Sum([]byte(key + wsGUID))
+    accept := base64.StdEncoding.EncodeToString(sum[:])
+    conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+    _, err = io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\n"+
+        "Upgrade: websocket\r\nConnection: Upgrade\r\n"+
+        "Sec-WebSocket-Accept: "+accept+"\r\n\r\n")
+    if err != nil {
+        conn.Close()
+        return nil, false
+    }
+
+    ws := &wsConn{conn: conn, r: rw.Reader, done: make(chan struct{})}
+    go ws.readLoop()
+    return ws, true
+}
+
+// Write a frame with the opcode and payload
+func (ws *wsConn) writeFrame(opcode byte, payload []byte) error {
+    ws.mu.Lock()
+    defer ws.mu.Unlock()
+    if ws.closed {
+        return net.ErrClosed
+    }
+    if opcode == wsClose {
+        ws.closed = true
+    }
+
+    // Final frame, server frames are not masked
+    header := []byte{0x80 | opcode, 0}
+    switch n := len(payload); {
+    case n < 126:
+        header[1] = byte(n)
+    case n <= 0xffff:
+        header[1] = 126
+        header = binary.BigEndian.AppendUint16(header, uint16(n))
+    default:
+        header[1] = 127
+        header = binary.BigEndian.AppendUint64(header, uint64(n))
+    }
+    ws.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+    _, err := (&net.Buffers{header, payload}).WriteTo(ws.conn)
+    return err
+}
+
+// Send a text message
+func (ws *wsConn) writeText(text []byte) error {
+    return ws.writeFrame(wsText, text)
+}
+
+// Start the close handshake with a status code, 1000 for normal closure,
+// and drop the connection once the client answers or is gone
+func (ws *wsConn) close(code uint16) {
+    ws.writeFrame(wsClose, binary.BigEndian.AppendUint16(nil, code))
+    select {
+    case <-ws.done:
+    case <-time.After(5 * time.Second):
+    }
+    ws.conn.Close()
+}
+
+// Read the frames of the client until it closes the connection, answering
+// pings
+func (ws *wsConn) readLoop() {
+    defer close(ws.done)
+    for {
+        opcode, payload, err := ws.readFrame()
+        if err != nil {
+            return
+        }
+        switch opcode {
+        case wsPing:
+            ws.writeFrame(wsPong, payload)
+        case wsClose:
+            ws.writeFrame(wsClose, payload[:min(len(payload), 2)])
+            return
+        }
+    }
+}
+
+// Read a frame of the client, ignoring messages that are too long
+func (ws *wsConn) readFrame() (byte, []byte, error) {
+    var header [2]byte
+    if _, err := io.ReadFull(ws.r, header[:]); err != nil {
+        return 0, nil, err
+    }
+    opcode := header[0] & 0x0f
+    if header[1]&0x80 == 0 {
+        return 0, nil, errors.New("unmasked frame of the client")
+    }
+
+    n := uint64(header[1] & 0x7f)
+    switch n {
+    case 126:
+        var ext [2]byte
+        if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+            return 0, nil, err
+        }
+        n = uint64(binary.BigEndian.Uint16(ext[:]))
+    case 127:
+        var ext [8]byte
+        if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+            return 0, nil, err
+        }
+        n = binary.BigEndian.Uint64(ext[:])
+    }
+    var mask [4]byte
+    if _, err := io.ReadFull(ws.r, mask[:]); err != nil {
+        return 0, nil, err
+    }
+    if n > wsMaxRead {
+        _, err := io.CopyN(io.Discard, ws.r, int64(n))
+        return 0, nil, err
+    }
+
+    payload := make([]byte, n)
+    if _, err := io.ReadFull(ws.r, payload); err != nil {
+        return 0, nil, err
+    }
+    for i := range payload {
+        payload[i] ^= mask[i%4]
+    }
+    return opcode, payload, nil
+}
@@ -0,0 +1,191 @@
+// The compressed digit files of y-cruncher, .ycd, for exchanging digits
+// with it.
+//
+// A file starts with a text header of tab separated keys and values,
+// ending in "EndHeader" and a zero byte:
+//
+//    #Compressed Digit File
+//
+//    FileVersion:    1.1.0
+//
+//    Base:           10
+//
+//    FirstDigits:    3.14159265358979323846264338327950288419716939937510
+//
+//    TotalDigits:    1000000
+//
+//    Blocksize:      1000000
+//    BlockID:        0
+//
+//    EndHeader
+//
+// The places after the point follow as little endian 64 bit words of 19
+// decimal or 16 hexadecimal digits each, the last word padded with zeros.
+// Large sets of digits are split in files of Blocksize places, BlockID
+// counts them from 0; the files written here hold all places in block 0.
+
+package main
+
+import (
+    "bufio"
+    "encoding/binary"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+)
+
+const ycdMagic = "#Compressed Digit File"
+
+// Number of places in the first digits of the header
+const ycdFirstDigits = 50
+
+// Return the number of digits per word for the base, 0 if not supported
+func ycdWordDigits(base int) int {
+    switch base {
+    case 10:
+        return 19
+    case 16:
+        return 16
+    }
+    return 0
+}
+
+// Write digits such as "3.1415..." in the given base, 10 or 16, as .ycd
+func writeYCD(w io.Writer, digits string, base int) error {
+    perWord := ycdWordDigits(base)
+    if perWord == 0 {
+        return fmt.Errorf("y-cruncher files hold base 10 or 16, not %d",
+            base)
+    }
+    point := strings.IndexByte(digits, '.')
+    places := ""
+    if point >= 0 {
+        places = digits[point+1:]
+    }
+    first := digits[:min(len(digits), len(digits)-len(places)+ycdFirstDigits)]
+
+    header := []string{
+        ycdMagic, "",
+        "FileVersion:\t1.1.0", "",
+        "Base:\t" + strconv.Itoa(base), "",
+        "FirstDigits:\t" + first, "",
+        "TotalDigits:\t" + strconv.Itoa(len(places)), "",
+        "Blocksize:\t" + strconv.Itoa(len(places)),
+        "BlockID:\t0", "",
+        "EndHeader", "",
+    }
+    text := strings.Join(header, "\r\n") + "\r\n\x00"
+    if _, err := io.WriteString(w, text); err != nil {
+        return err
+    }
+
+    var word [8]byte
+    for len(places) > 0 {
+        n := min(len(places), perWord)
+        chunk := places[:n] + strings.Repeat("0", perWord-n)
+        x, err := strconv.ParseUint(chunk, base, 64)
+        if err != nil {
+            return err
+        }
+        binary.LittleEndian.PutUint64(word[:], x)
+        if _, err := w.Write(word[:]); err != nil {
+            return err
+        }
+        places = places[n:]
+    }
+    return nil
+}
+
+// Reads the places of a .ycd file as ASCII digits, lower case in base 16
+type ycdReader struct {
+    r       *bufio.Reader
+    base    int
+    places  int64  // still to be read
+    pending []byte // digits of the current word not read yet
+    word    []byte
+}
+
+// Return a reader of the places of the .ycd file read by r, after its
+// header
+func newYCDReader(r *bufio.Reader) (*ycdReader, error) {
+    header := map[string]string{}
+    for {
+        line, err := r.ReadString('\n')
+        if err != nil {
+            return nil, fmt.Errorf("incomplete y-cruncher header: %v", err)
+        }
+        line = strings.TrimSpace(line)
+        if line == "EndHeader" {
+            break
+        }
+        if key, value, ok := strings.Cut(line, ":"); ok {
+            header[key] = strings.TrimSpace(value)
+        }
+    }
+    // The header ends with a zero byte after the blank line
+    if _, err := r.ReadString(0); err != nil {
+        return nil, fmt.Errorf("incomplete y-cruncher header: %v", err)
+    }
+
+    base, _ := strconv.Atoi(header["Base"])
+    perWord := ycdWordDigits(base)
+    if perWord == 0 {
+        return nil, fmt.Errorf("y-cruncher file of base %s, only base 10 "+
+            "and 16 are supported", header["Base"])
+    }
+    total, err1 := strconv.ParseInt(header["TotalDigits"], 10, 64)
+    size, err2 := strconv.ParseInt(header["Blocksize"], 10, 64)
+    id, err3 := strconv.ParseInt(header["BlockID"], 10, 64)
+    if err1 != nil || err2 != nil || err3 != nil || size <= 0 {
+        return nil, fmt.Errorf("invalid y-cruncher header")
+    }
+    if id != 0 {
+        return nil, fmt.Errorf("y-cruncher block %d, only the first block "+
+            "starting right after the point is supported", id)
+    }
+
+    places := size
+    if total > 0 {
+        places = min(size, total)
+    }
+    return &ycdReader{r: r, base: base, places: places,
+        word: make([]byte, perWord)}, nil
+}
+
+func (y *ycdReader) Read(p []byte) (int, error) {
+    n := 0
+    for n < len(p) {
+        if len(y.pending) == 0 {
+            if y.places == 0 {
+                break
+            }
+            var word [8]byte
+            if _, err := io.ReadFull(y.r, word[:]); err != nil {
+                if err == io.EOF {
+                    err = io.ErrUnexpectedEOF
+                }
+                return n, err
+            }
+            x := binary.LittleEndian.Uint64(word[:])
+            if y.base == 10 && x >= 1e19 {
+                return n, fmt.Errorf("invalid y-cruncher word %d", x)
+            }
+            digits := strconv.AppendUint(y.word[:0], x, y.base)
+            pad := len(y.word) - len(digits)
+            copy(y.word[pad:], digits)
+            for i := 0; i < pad; i++ {
+                y.word[i] = '0'
+            }
+            y.pending = y.word[:min(int64(len(y.word)), y.places)]
+            y.places -= int64(len(y.pending))
+        }
+        m := copy(p[n:], y.pending)
+        y.pending = y.pending[m:]
+        n += m
+    }
+    if n == 0 {
+        return 0, io.EOF
+    }
+    return n, nil
+}